@@ -0,0 +1,53 @@
+// parserc-gen 根据PEG语法规则文件生成使用parserc组合子的Go源码
+//
+// 用法：
+//
+//	//go:generate go run parserc-go/cmd/parserc-gen -actions actions grammar.peg
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"parserc-go/parserc"
+	"strings"
+)
+
+func main() {
+	output := flag.String("o", "", "output file, defaults to the grammar file name with a _gen.go suffix")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated code, defaults to $GOPACKAGE")
+	importPath := flag.String("import", "", "import path of the parserc package")
+	actions := flag.String("actions", "", "name of a map[string]func(any) any variable holding rule actions")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: parserc-gen [flags] grammar.peg")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	input := flag.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(input, ".peg") + "_gen.go"
+	}
+	src, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code, err := parserc.GenerateGo(string(src), parserc.GenOptions{
+		Package: *pkg,
+		Import:  *importPath,
+		Actions: *actions,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", input, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
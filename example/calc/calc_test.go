@@ -29,3 +29,29 @@ func TestEval(t *testing.T) {
 	testEvalFailed(t, "a + 12")
 	testEvalFailed(t, " 1 2  4")
 }
+
+const sample = "77.58* ( 6 / 3.14+55.2234 ) -2 * 6.1/ ( 1.0+2/ (4.0-3.8*5))  "
+
+func TestEvalCompiled(t *testing.T) {
+	compiled := expr.Compile()
+	inputs := []string{sample, "(2+3)*(7-4)", "", "1+", "1 * (2 + 3", "1 * 2 + 3)", "a + 12", " 1 2  4"}
+	for _, s := range inputs {
+		r1, err1 := expr.ParseToEnd(s)
+		r2, err2 := compiled.ParseToEnd(s)
+		assert.Equal(t, r1, r2, s)
+		assert.Equal(t, err1, err2, s)
+	}
+}
+
+func BenchmarkEval(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = expr.ParseToEnd(sample)
+	}
+}
+
+func BenchmarkEvalCompiled(b *testing.B) {
+	compiled := expr.Compile()
+	for i := 0; i < b.N; i++ {
+		_, _ = compiled.ParseToEnd(sample)
+	}
+}
@@ -0,0 +1,57 @@
+package csv
+
+//go:generate go run parserc-go/cmd/parserc-gen -actions actions csv.peg
+
+import "strings"
+
+func join(list any) string {
+	var sb strings.Builder
+	for _, e := range list.([]any) {
+		switch v := e.(type) {
+		case rune:
+			sb.WriteRune(v)
+		case string:
+			sb.WriteString(v[:1])
+		}
+	}
+	return sb.String()
+}
+
+func separated(rs any) []any {
+	result := []any{rs.([]any)[0]}
+	for _, e := range rs.([]any)[1].([]any) {
+		result = append(result, e.([]any)[len(e.([]any))-1])
+	}
+	return result
+}
+
+var actions = map[string]func(any) any{
+	"file": func(rs any) any {
+		records := make([][]string, 0)
+		for _, r := range separated(rs) {
+			records = append(records, r.([]string))
+		}
+		return records
+	},
+	"record": func(rs any) any {
+		fields := make([]string, 0)
+		for _, f := range separated(rs) {
+			fields = append(fields, f.(string))
+		}
+		return fields
+	},
+	"quoted": func(rs any) any {
+		return join(rs.([]any)[1])
+	},
+	"plain": func(cs any) any {
+		return join(cs)
+	},
+}
+
+func Parse(s string) [][]string {
+	r, err := File.ParseToEnd(s)
+	if err != nil {
+		panic(err)
+	}
+	return r.([][]string)
+}
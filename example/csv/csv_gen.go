@@ -0,0 +1,30 @@
+// Code generated by parserc-gen. DO NOT EDIT.
+
+package csv
+
+import parserc "parserc-go/parserc"
+
+var (
+	File    = parserc.NewParser().Named("file")
+	Record  = parserc.NewParser().Named("record")
+	Field   = parserc.NewParser().Named("field")
+	Quoted  = parserc.NewParser().Named("quoted")
+	Plain   = parserc.NewParser().Named("plain")
+	Newline = parserc.NewParser().Named("newline")
+)
+
+func init() {
+	File.Set(withAction("file", parserc.Seq(Record, parserc.Seq(Newline, parserc.Peek(parserc.Any(), parserc.Succeed(nil), parserc.Fail("predicate failed")), Record).Many(), Newline.Opt(nil))))
+	Record.Set(withAction("record", parserc.Seq(Field, parserc.Seq(parserc.Str(","), Field).Many())))
+	Field.Set(withAction("field", parserc.OneOf(Quoted, Plain)))
+	Quoted.Set(withAction("quoted", parserc.Seq(parserc.Str("\""), parserc.OneOf(parserc.Str("\"\""), parserc.CharClass(true, '"', '"')).Many(), parserc.Str("\""))))
+	Plain.Set(withAction("plain", parserc.CharClass(true, ',', ',', '"', '"', '\r', '\r', '\n', '\n').Many()))
+	Newline.Set(withAction("newline", parserc.Seq(parserc.Str("\r").Opt(nil), parserc.Str("\n"))))
+}
+
+func withAction(name string, p *parserc.Parser) *parserc.Parser {
+	if action, exist := actions[name]; exist {
+		return p.Map(action)
+	}
+	return p
+}
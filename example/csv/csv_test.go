@@ -0,0 +1,47 @@
+package csv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"parserc-go/parserc"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	assert.Equal(t, [][]string{{"a", "b", "c"}}, Parse("a,b,c"))
+	assert.Equal(t, [][]string{{"a", "b"}, {"1", "2"}}, Parse("a,b\n1,2\n"))
+	assert.Equal(t, [][]string{{"name", "quote"}, {"Xiao Ming", "say \"hi\", ok"}}, Parse("name,quote\r\nXiao Ming,\"say \"\"hi\"\", ok\"\r\n"))
+	assert.Equal(t, [][]string{{"", ""}}, Parse(","))
+
+	assert.Panics(t, func() {
+		Parse("a,\"b")
+	})
+}
+
+func TestGeneratedUpToDate(t *testing.T) {
+	peg, err := os.ReadFile("csv.peg")
+	assert.Nil(t, err)
+	code, err := parserc.GenerateGo(string(peg), parserc.GenOptions{Package: "csv", Actions: "actions"})
+	assert.Nil(t, err)
+	generated, err := os.ReadFile("csv_gen.go")
+	assert.Nil(t, err)
+	assert.Equal(t, string(code), string(generated))
+}
+
+func TestGeneratedMatchesGrammar(t *testing.T) {
+	peg, err := os.ReadFile("csv.peg")
+	assert.Nil(t, err)
+	g, err := parserc.CompileGrammar(string(peg), actions)
+	assert.Nil(t, err)
+	rules := map[string]*parserc.Parser{"file": File, "record": Record, "field": Field, "quoted": Quoted, "plain": Plain, "newline": Newline}
+	inputs := []string{"a,b,c", "a,b\n1,2\n", "name,quote\r\nXiao Ming,\"say \"\"hi\"\", ok\"\r\n", ",", "", "a,\"b", "\"a\"b", "\r", "\n"}
+	for name, p := range rules {
+		assert.Equal(t, g.Rule(name).String(), p.String(), name)
+		for _, s := range inputs {
+			r1, err1 := g.Rule(name).ParseToEnd(s)
+			r2, err2 := p.ParseToEnd(s)
+			assert.Equal(t, r1, r2, "%s %q", name, s)
+			assert.Equal(t, err1, err2, "%s %q", name, s)
+		}
+	}
+}
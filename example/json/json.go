@@ -54,7 +54,7 @@ var (
 	integer  = digits.Map(toInt).Surround(ws)
 	decimal  = Seq(digits, Ch('.'), digits).Surround(ws).Map(join).Map(toFloat)
 	str      = Skip(Ch('"')).And(Not('"').Many()).Skip(Ch('"')).Map(join).Surround(ws)
-	boolean  = Str("true").Or(Str("false")).Map(toBool).Surround(ws)
+	boolean  = Keyword("true").Or(Keyword("false")).Map(toBool).Surround(ws)
 	objStart = Ch('{').Surround(ws)
 	objEnd   = Ch('}').Surround(ws)
 	arrStart = Ch('[').Surround(ws)
@@ -62,9 +62,9 @@ var (
 	colon    = Ch(':').Surround(ws)
 	comma    = Ch(',').Surround(ws)
 	jsonObj  = NewParser()
-	arr      = Skip(arrStart).And(Separate(comma, jsonObj).Opt([]any{})).Skip(arrEnd)
+	arr      = Skip(arrStart).And(SepBy(comma, jsonObj)).Skip(arrEnd)
 	pair     = str.Skip(colon).And(jsonObj)
-	obj      = Skip(objStart).And(Separate(comma, pair).Opt([]any{})).Skip(objEnd).Map(buildObj)
+	obj      = Skip(objStart).And(SepBy(comma, pair)).Skip(objEnd).Map(buildObj)
 )
 
 func init() {
@@ -1,34 +1,39 @@
 package json
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	. "parserc-go/parserc"
+	"strings"
 	"testing"
 )
 
-func TestParse(t *testing.T) {
-	json := `
-	{
-		"a": 123,
-		"b": 3.14,
-		"c": "hello",
-		"d": {
-			"x": 100,
-			"y": "world!"
+const sample = `
+{
+	"a": 123,
+	"b": 3.14,
+	"c": "hello",
+	"d": {
+		"x": 100,
+		"y": "world!"
+	},
+	"e": [
+		12,
+		34.56,
+		{
+			"name": "Xiao Ming",
+			"age": 18,
+			"score": [99.8, 87.5, 60.0]
 		},
-		"e": [
-			12,
-			34.56,
-			{
-				"name": "Xiao Ming",
-				"age": 18,
-				"score": [99.8, 87.5, 60.0]
-			},
-			"abc"
-		],
-		"f": [],
-		"g": {},
-		"h": [true, {"m": false}]
-	}`
+		"abc"
+	],
+	"f": [],
+	"g": {},
+	"h": [true, {"m": false}]
+}`
+
+func TestParse(t *testing.T) {
 	m := map[string]interface{}{
 		"a": 123,
 		"b": 3.14,
@@ -52,6 +57,40 @@ func TestParse(t *testing.T) {
 		"h": []interface{}{true, map[string]interface{}{"m": false}},
 	}
 
-	r := Parse(json)
+	r := Parse(sample)
 	assert.Equal(t, m, r)
 }
+
+func TestParseDepthLimit(t *testing.T) {
+	deep := strings.Repeat("[", 100) + strings.Repeat("]", 100)
+	_, err := jsonObj.ParseToEndContext(context.Background(), deep, WithMaxDepth(50))
+	var depthErr *DepthLimitError
+	assert.True(t, errors.As(err, &depthErr))
+	r, err := jsonObj.ParseToEndContext(context.Background(), "[[1]]", WithMaxDepth(50))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{[]any{1}}, r)
+}
+
+func TestParseCompiled(t *testing.T) {
+	compiled := jsonObj.Compile()
+	inputs := []string{sample, "[]", " [1, 2.5, \"a\", true, false] ", "[1,", "{\"a\" 1}", "[tru]", "[\"abc", "{} x", ""}
+	for _, s := range inputs {
+		r1, err1 := jsonObj.ParseToEnd(s)
+		r2, err2 := compiled.ParseToEnd(s)
+		assert.Equal(t, r1, r2, s)
+		assert.Equal(t, err1, err2, s)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = jsonObj.ParseToEnd(sample)
+	}
+}
+
+func BenchmarkParseCompiled(b *testing.B) {
+	compiled := jsonObj.Compile()
+	for i := 0; i < b.N; i++ {
+		_, _ = compiled.ParseToEnd(sample)
+	}
+}
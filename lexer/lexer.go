@@ -0,0 +1,183 @@
+package lexer
+
+import (
+	"fmt"
+	"parserc-go/parserc"
+)
+
+// DefaultMode 词法分析开始时所处的模式
+const DefaultMode = "default"
+
+// Token 词法单元，实现了parserc.Token，可通过parserc.CreateTokenInput交给解析器解析
+type Token struct {
+	Type  string // 词法单元类别，即规则名称
+	Text  string // 词法单元的原始文本
+	Value any    // 规则解析器的解析结果，字面量与正则表达式规则为原始文本
+	Row   int    // 行号
+	Col   int    // 列号
+}
+
+// Kind 获取词法单元类别
+func (t Token) Kind() any {
+	return t.Type
+}
+
+// Pos 获取词法单元的行号与列号
+func (t Token) Pos() (int, int) {
+	return t.Row, t.Col
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s(%q)", t.Type, t.Text)
+}
+
+// Rule 词法规则
+type Rule struct {
+	kind     string
+	parser   *parserc.Parser
+	priority int
+	skip     bool
+	push     string
+	pop      bool
+}
+
+// Priority 设置规则的优先级，多个规则匹配到相同长度的文本时选择优先级最高的规则，默认为0
+func (r *Rule) Priority(n int) *Rule {
+	r.priority = n
+	return r
+}
+
+// Skip 匹配的文本不产生词法单元，用于空白与注释
+func (r *Rule) Skip() *Rule {
+	r.skip = true
+	return r
+}
+
+// Push 匹配后进入指定模式
+func (r *Rule) Push(mode string) *Rule {
+	r.push = mode
+	return r
+}
+
+// Pop 匹配后返回进入当前模式之前的模式
+func (r *Rule) Pop() *Rule {
+	r.pop = true
+	return r
+}
+
+// Mode 词法分析模式，每个模式拥有独立的规则，用于字符串插值、heredoc等上下文相关的词法
+type Mode struct {
+	name  string
+	rules []*Rule
+}
+
+// Literal 添加匹配字面量的规则
+func (m *Mode) Literal(kind string, text string) *Rule {
+	return m.Parser(kind, parserc.Str(text))
+}
+
+// Regex 添加匹配正则表达式的规则，pattern有误时panic
+func (m *Mode) Regex(kind string, pattern string) *Rule {
+	return m.Parser(kind, parserc.Regex(pattern))
+}
+
+// Parser 添加由解析器匹配的规则，解析结果作为词法单元的Value
+func (m *Mode) Parser(kind string, p *parserc.Parser) *Rule {
+	r := &Rule{kind: kind, parser: p}
+	m.rules = append(m.rules, r)
+	return r
+}
+
+// Lexer 词法分析器
+type Lexer struct {
+	modes map[string]*Mode
+	order []*Mode // 按创建顺序排列的模式，使规则检查的结果确定
+}
+
+// New 创建词法分析器
+func New() *Lexer {
+	l := &Lexer{modes: make(map[string]*Mode)}
+	l.Mode(DefaultMode)
+	return l
+}
+
+// Mode 获取指定名称的模式，不存在时创建
+func (l *Lexer) Mode(name string) *Mode {
+	m, exist := l.modes[name]
+	if !exist {
+		m = &Mode{name: name}
+		l.modes[name] = m
+		l.order = append(l.order, m)
+	}
+	return m
+}
+
+// Literal 在默认模式中添加匹配字面量的规则
+func (l *Lexer) Literal(kind string, text string) *Rule {
+	return l.Mode(DefaultMode).Literal(kind, text)
+}
+
+// Regex 在默认模式中添加匹配正则表达式的规则，pattern有误时panic
+func (l *Lexer) Regex(kind string, pattern string) *Rule {
+	return l.Mode(DefaultMode).Regex(kind, pattern)
+}
+
+// Parser 在默认模式中添加由解析器匹配的规则
+func (l *Lexer) Parser(kind string, p *parserc.Parser) *Rule {
+	return l.Mode(DefaultMode).Parser(kind, p)
+}
+
+func lexError(input parserc.Input, msg string) error {
+	return fmt.Errorf("lexical error at row %d, col %d: %s", input.Row(), input.Col(), msg)
+}
+
+// Tokenize 将s切分为词法单元。每一步在当前模式的规则中选择匹配文本最长的规则，
+// 长度相同时选择优先级最高的规则，优先级也相同时选择最先添加的规则
+func (l *Lexer) Tokenize(s string) ([]Token, error) {
+	for _, m := range l.order {
+		for _, r := range m.rules {
+			if _, exist := l.modes[r.push]; r.push != "" && !exist {
+				return nil, fmt.Errorf("rule %s in mode %s pushes undefined mode %s", r.kind, m.name, r.push)
+			}
+		}
+	}
+	tokens := make([]Token, 0)
+	stack := []*Mode{l.modes[DefaultMode]}
+	input := parserc.CreateInput(s)
+	for !input.End() {
+		mode := stack[len(stack)-1]
+		var best *Rule
+		var bestResult parserc.ParseResult
+		bestText := ""
+		for _, r := range mode.rules {
+			result, err := r.parser.Parse(input)
+			if err != nil {
+				continue
+			}
+			text := input.TextTo(result.Remain)
+			if text == "" {
+				continue
+			}
+			if best == nil || len(text) > len(bestText) || len(text) == len(bestText) && r.priority > best.priority {
+				best, bestResult, bestText = r, result, text
+			}
+		}
+		if best == nil {
+			return nil, lexError(input, fmt.Sprintf("unexpected %q in mode %s", input.Current(), mode.name))
+		}
+		if !best.skip {
+			tokens = append(tokens, Token{best.kind, bestText, bestResult.Result, input.Row(), input.Col()})
+		}
+		if best.pop {
+			if len(stack) == 1 {
+				return nil, lexError(input, fmt.Sprintf("%s cannot leave mode %s", best.kind, mode.name))
+			}
+			stack = stack[:len(stack)-1]
+		}
+		if best.push != "" {
+			stack = append(stack, l.modes[best.push])
+		}
+		input = bestResult.Remain
+	}
+	return tokens, nil
+}
@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"parserc-go/parserc"
+	"strconv"
+	"testing"
+)
+
+func kinds(tokens []Token) []string {
+	ks := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		ks = append(ks, t.Type)
+	}
+	return ks
+}
+
+func exprLexer() *Lexer {
+	l := New()
+	l.Regex("WS", `[ \t\r\n]+`).Skip()
+	l.Regex("COMMENT", `#[^\n]*`).Skip()
+	l.Regex("IDENT", `[a-zA-Z_][a-zA-Z0-9_]*`)
+	l.Literal("IF", "if").Priority(1)
+	l.Parser("NUMBER", parserc.Range('0', '9').Many1().Map(func(r any) any {
+		n := 0
+		for _, c := range r.([]any) {
+			n = n*10 + int(c.(rune)-'0')
+		}
+		return n
+	}))
+	l.Literal("EQ", "=")
+	l.Literal("EQEQ", "==")
+	return l
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := exprLexer().Tokenize("if iffy == 42 # comment\n  x = 7")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"IF", "IDENT", "EQEQ", "NUMBER", "IDENT", "EQ", "NUMBER"}, kinds(tokens))
+	assert.Equal(t, Token{"NUMBER", "42", 42, 1, 12}, tokens[3])
+	assert.Equal(t, Token{"IDENT", "x", "x", 2, 3}, tokens[4])
+	tokens, err = exprLexer().Tokenize("")
+	assert.Nil(t, err)
+	assert.Equal(t, []Token{}, tokens)
+}
+
+func TestTokenizeError(t *testing.T) {
+	_, err := exprLexer().Tokenize("x = 1\ny = $")
+	assert.Equal(t, `lexical error at row 2, col 5: unexpected '$' in mode default`, err.Error())
+	l := New()
+	l.Literal("A", "a").Push("missing")
+	_, err = l.Tokenize("a")
+	assert.Equal(t, "rule A in mode default pushes undefined mode missing", err.Error())
+	l = New()
+	for _, name := range []string{"m1", "m2", "m3", "m4", "m5", "m6", "m7", "m8"} {
+		l.Mode(name).Literal("B", "b").Push("missing-" + name)
+	}
+	l.Literal("A", "a").Push("missing")
+	for k := 0; k < 10; k++ {
+		_, err = l.Tokenize("a")
+		assert.Equal(t, "rule A in mode default pushes undefined mode missing", err.Error())
+	}
+	l = New()
+	l.Literal("RBRACE", "}").Pop()
+	_, err = l.Tokenize("}")
+	assert.Equal(t, "lexical error at row 1, col 1: RBRACE cannot leave mode default", err.Error())
+}
+
+func interpolationLexer() *Lexer {
+	l := New()
+	l.Regex("WS", `[ ]+`).Skip()
+	l.Regex("IDENT", `[a-z]+`)
+	l.Literal("PLUS", "+")
+	l.Literal("QUOTE", `"`).Push("string")
+	l.Literal("RBRACE", "}").Pop()
+	s := l.Mode("string")
+	s.Regex("CHARS", `[^"$]+`)
+	s.Literal("INTERP", "${").Push(DefaultMode)
+	s.Literal("QUOTE", `"`).Pop()
+	return l
+}
+
+func TestTokenizeModes(t *testing.T) {
+	tokens, err := interpolationLexer().Tokenize(`"a ${x + "b ${y}"} c"`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"QUOTE", "CHARS", "INTERP", "IDENT", "PLUS", "QUOTE", "CHARS", "INTERP", "IDENT", "RBRACE", "QUOTE", "RBRACE", "CHARS", "QUOTE"}, kinds(tokens))
+	assert.Equal(t, "b ", tokens[6].Text)
+	_, err = interpolationLexer().Tokenize(`"a ${x $}"`)
+	assert.Equal(t, `lexical error at row 1, col 8: unexpected '$' in mode default`, err.Error())
+}
+
+func TestTokensToParser(t *testing.T) {
+	tokens, err := exprLexer().Tokenize("x = 1 y = 22")
+	assert.Nil(t, err)
+	assign := parserc.TokenKind("IDENT").Skip(parserc.TokenKind("EQ")).And(parserc.TokenKind("NUMBER")).Map(func(r any) any {
+		p := r.(parserc.Pair)
+		return p.First.(Token).Text + "=" + strconv.Itoa(p.Second.(Token).Value.(int))
+	})
+	r, err := assign.Many().ParseInput(parserc.CreateTokenInput(tokens))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{"x=1", "y=22"}, r)
+	_, err = assign.ParseInput(parserc.CreateTokenInput(tokens[:2]))
+	assert.Equal(t, `parse error at row 1, col 3: expected NUMBER, found end of input`, err.Error())
+}
@@ -0,0 +1,324 @@
+package parserc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// readBytes 从字节输入中读取n个字节
+func readBytes(input Input, n int) ([]byte, Input, error) {
+	if !input.binary {
+		return nil, input, parseError(input, "binary parser requires byte input")
+	}
+	i := input
+	for k := 0; k < n; k++ {
+		if i.eof() {
+			return nil, input, parseError(i, "unexpected end of input")
+		}
+		i = i.Next()
+	}
+	return []byte(input.str[input.index:i.index]), i, nil
+}
+
+func fixed(kind string, n int, decode func([]byte) any) *Parser {
+	return &Parser{kind: kind, parse: func(input Input) (ParseResult, error) {
+		b, remain, err := readBytes(input, n)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{decode(b), remain}, nil
+	}}
+}
+
+// Byte 匹配任意字节，解析结果为byte
+func Byte() *Parser {
+	return fixed("Byte", 1, func(b []byte) any {
+		return b[0]
+	})
+}
+
+// ByteEq 匹配指定字节
+func ByteEq(c byte) *Parser {
+	return &Parser{kind: "ByteEq", args: []any{c}, parse: func(input Input) (ParseResult, error) {
+		b, remain, err := readBytes(input, 1)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		if b[0] != c {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected %02x", c))
+		}
+		return ParseResult{c, remain}, nil
+	}}
+}
+
+// BytesEq 匹配指定字节序列，解析结果为[]byte
+func BytesEq(bs ...byte) *Parser {
+	return &Parser{kind: "BytesEq", args: []any{bs}, parse: func(input Input) (ParseResult, error) {
+		b, remain, err := readBytes(input, len(bs))
+		if err != nil {
+			return emptyParseResult, err
+		}
+		if !bytes.Equal(b, bs) {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected % x", bs))
+		}
+		return ParseResult{b, remain}, nil
+	}}
+}
+
+// Bytes 匹配n个任意字节，解析结果为[]byte
+func Bytes(n int) *Parser {
+	return &Parser{kind: "Bytes", args: []any{n}, parse: func(input Input) (ParseResult, error) {
+		b, remain, err := readBytes(input, n)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{b, remain}, nil
+	}}
+}
+
+// Int8 匹配有符号8位整数
+func Int8() *Parser {
+	return fixed("Int8", 1, func(b []byte) any {
+		return int8(b[0])
+	})
+}
+
+// Uint16BE 匹配大端序无符号16位整数
+func Uint16BE() *Parser {
+	return fixed("Uint16BE", 2, func(b []byte) any {
+		return binary.BigEndian.Uint16(b)
+	})
+}
+
+// Uint16LE 匹配小端序无符号16位整数
+func Uint16LE() *Parser {
+	return fixed("Uint16LE", 2, func(b []byte) any {
+		return binary.LittleEndian.Uint16(b)
+	})
+}
+
+// Uint32BE 匹配大端序无符号32位整数
+func Uint32BE() *Parser {
+	return fixed("Uint32BE", 4, func(b []byte) any {
+		return binary.BigEndian.Uint32(b)
+	})
+}
+
+// Uint32LE 匹配小端序无符号32位整数
+func Uint32LE() *Parser {
+	return fixed("Uint32LE", 4, func(b []byte) any {
+		return binary.LittleEndian.Uint32(b)
+	})
+}
+
+// Uint64BE 匹配大端序无符号64位整数
+func Uint64BE() *Parser {
+	return fixed("Uint64BE", 8, func(b []byte) any {
+		return binary.BigEndian.Uint64(b)
+	})
+}
+
+// Uint64LE 匹配小端序无符号64位整数
+func Uint64LE() *Parser {
+	return fixed("Uint64LE", 8, func(b []byte) any {
+		return binary.LittleEndian.Uint64(b)
+	})
+}
+
+// Int16BE 匹配大端序有符号16位整数
+func Int16BE() *Parser {
+	return fixed("Int16BE", 2, func(b []byte) any {
+		return int16(binary.BigEndian.Uint16(b))
+	})
+}
+
+// Int16LE 匹配小端序有符号16位整数
+func Int16LE() *Parser {
+	return fixed("Int16LE", 2, func(b []byte) any {
+		return int16(binary.LittleEndian.Uint16(b))
+	})
+}
+
+// Int32BE 匹配大端序有符号32位整数
+func Int32BE() *Parser {
+	return fixed("Int32BE", 4, func(b []byte) any {
+		return int32(binary.BigEndian.Uint32(b))
+	})
+}
+
+// Int32LE 匹配小端序有符号32位整数
+func Int32LE() *Parser {
+	return fixed("Int32LE", 4, func(b []byte) any {
+		return int32(binary.LittleEndian.Uint32(b))
+	})
+}
+
+// Int64BE 匹配大端序有符号64位整数
+func Int64BE() *Parser {
+	return fixed("Int64BE", 8, func(b []byte) any {
+		return int64(binary.BigEndian.Uint64(b))
+	})
+}
+
+// Int64LE 匹配小端序有符号64位整数
+func Int64LE() *Parser {
+	return fixed("Int64LE", 8, func(b []byte) any {
+		return int64(binary.LittleEndian.Uint64(b))
+	})
+}
+
+// Float32BE 匹配大端序IEEE 754单精度浮点数
+func Float32BE() *Parser {
+	return fixed("Float32BE", 4, func(b []byte) any {
+		return math.Float32frombits(binary.BigEndian.Uint32(b))
+	})
+}
+
+// Float32LE 匹配小端序IEEE 754单精度浮点数
+func Float32LE() *Parser {
+	return fixed("Float32LE", 4, func(b []byte) any {
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	})
+}
+
+// Float64BE 匹配大端序IEEE 754双精度浮点数
+func Float64BE() *Parser {
+	return fixed("Float64BE", 8, func(b []byte) any {
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	})
+}
+
+// Float64LE 匹配小端序IEEE 754双精度浮点数
+func Float64LE() *Parser {
+	return fixed("Float64LE", 8, func(b []byte) any {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	})
+}
+
+// leb128 读取LEB128编码的字节，返回按7位分组拼接的值与最后一组的位偏移
+func leb128(input Input, signed bool) (uint64, uint, Input, error) {
+	if !input.binary {
+		return 0, 0, input, parseError(input, "binary parser requires byte input")
+	}
+	var v uint64
+	var shift uint
+	i := input
+	for {
+		if i.eof() {
+			return 0, 0, input, parseError(i, "unexpected end of input")
+		}
+		b := i.str[i.index]
+		// 第10组只剩最高位：无符号数只能为0或1，有符号数的其余位必须是最高位的符号扩展
+		if shift == 63 && (signed && b != 0 && b != 0x7f || !signed && b > 1) {
+			return 0, 0, input, parseError(input, "varint overflows 64 bits")
+		}
+		v |= uint64(b&0x7f) << shift
+		i = i.Next()
+		if b < 0x80 {
+			return v, shift, i, nil
+		}
+		shift += 7
+	}
+}
+
+// Uvarint 匹配无符号LEB128编码的整数，即protobuf的varint，解析结果为uint64
+func Uvarint() *Parser {
+	return &Parser{kind: "Uvarint", parse: func(input Input) (ParseResult, error) {
+		v, _, remain, err := leb128(input, false)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{v, remain}, nil
+	}}
+}
+
+// Varint 匹配ZigZag编码后以LEB128存储的有符号整数，即protobuf的sint64，与encoding/binary的Varint相同，解析结果为int64
+func Varint() *Parser {
+	return &Parser{kind: "Varint", parse: func(input Input) (ParseResult, error) {
+		v, _, remain, err := leb128(input, false)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{int64(v>>1) ^ -int64(v&1), remain}, nil
+	}}
+}
+
+// Sleb128 匹配有符号LEB128编码的整数，解析结果为int64
+func Sleb128() *Parser {
+	return &Parser{kind: "Sleb128", parse: func(input Input) (ParseResult, error) {
+		v, shift, remain, err := leb128(input, true)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		last := remain.str[remain.index-1]
+		if shift+7 < 64 && last&0x40 != 0 {
+			v |= ^uint64(0) << (shift + 7)
+		}
+		return ParseResult{int64(v), remain}, nil
+	}}
+}
+
+// LengthPrefixed 先以length解析出长度，再匹配相应数量的字节，解析结果为[]byte。
+// length的解析结果可以是任意整数类型
+func LengthPrefixed(length *Parser) *Parser {
+	return &Parser{kind: "LengthPrefixed", children: []*Parser{length}, parse: func(input Input) (ParseResult, error) {
+		r, err := length.run(input)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		n, ok := toLength(r.Result)
+		if !ok {
+			return emptyParseResult, parseError(input, fmt.Sprintf("invalid length %v", r.Result))
+		}
+		b, remain, err := readBytes(r.Remain, n)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{b, remain}, nil
+	}}
+}
+
+func toLength(v any) (int, bool) {
+	var n int64
+	switch x := v.(type) {
+	case int:
+		n = int64(x)
+	case int8:
+		n = int64(x)
+	case int16:
+		n = int64(x)
+	case int32:
+		n = int64(x)
+	case int64:
+		n = x
+	case uint8:
+		n = int64(x)
+	case uint16:
+		n = int64(x)
+	case uint32:
+		n = int64(x)
+	case uint64:
+		if x > math.MaxInt32 {
+			return 0, false
+		}
+		n = int64(x)
+	case uint:
+		if uint64(x) > math.MaxInt32 {
+			return 0, false
+		}
+		n = int64(x)
+	default:
+		return 0, false
+	}
+	if n < 0 || n > math.MaxInt32 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// ParseBytes 将b作为字节输入解析直到末尾
+func (p Parser) ParseBytes(b []byte) (any, error) {
+	return p.parseToEnd(CreateBytesInput(b))
+}
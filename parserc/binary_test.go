@@ -0,0 +1,118 @@
+package parserc
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func verifyBytes(t *testing.T, p *Parser, b []byte, expected any) {
+	r, err := p.ParseBytes(b)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, r)
+}
+
+func verifyBytesFailed(t *testing.T, p *Parser, b []byte) {
+	_, err := p.ParseBytes(b)
+	assert.NotNil(t, err)
+}
+
+func TestByte(t *testing.T) {
+	verifyBytes(t, Byte(), []byte{0xff}, byte(0xff))
+	verifyBytesFailed(t, Byte(), []byte{})
+	verifyBytes(t, ByteEq(0x89), []byte{0x89}, byte(0x89))
+	verifyBytesFailed(t, ByteEq(0x89), []byte{0x88})
+	verifyBytes(t, Bytes(3), []byte{1, 2, 3}, []byte{1, 2, 3})
+	verifyBytesFailed(t, Bytes(3), []byte{1, 2})
+	verifyBytes(t, BytesEq(0x89, 'P', 'N', 'G'), []byte("\x89PNG"), []byte("\x89PNG"))
+	verifyBytesFailed(t, BytesEq(0x89, 'P', 'N', 'G'), []byte("\x89PNJ"))
+	verifyBytes(t, Int8(), []byte{0xfe}, int8(-2))
+	_, err := Byte().ParseToEnd("a")
+	assert.Equal(t, "parse error at row 1, col 1: binary parser requires byte input", err.Error())
+}
+
+func TestFixedIntegers(t *testing.T) {
+	verifyBytes(t, Uint16BE(), []byte{0x12, 0x34}, uint16(0x1234))
+	verifyBytes(t, Uint16LE(), []byte{0x12, 0x34}, uint16(0x3412))
+	verifyBytes(t, Uint32BE(), []byte{1, 2, 3, 4}, uint32(0x01020304))
+	verifyBytes(t, Uint32LE(), []byte{1, 2, 3, 4}, uint32(0x04030201))
+	verifyBytes(t, Uint64BE(), []byte{0, 0, 0, 0, 0, 0, 1, 0}, uint64(256))
+	verifyBytes(t, Uint64LE(), []byte{0, 1, 0, 0, 0, 0, 0, 0}, uint64(256))
+	verifyBytes(t, Int16BE(), []byte{0xff, 0xfe}, int16(-2))
+	verifyBytes(t, Int16LE(), []byte{0xfe, 0xff}, int16(-2))
+	verifyBytes(t, Int32BE(), []byte{0xff, 0xff, 0xff, 0xfd}, int32(-3))
+	verifyBytes(t, Int32LE(), []byte{0xfd, 0xff, 0xff, 0xff}, int32(-3))
+	verifyBytes(t, Int64BE(), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(-1))
+	verifyBytes(t, Int64LE(), []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, int64(-2))
+	verifyBytesFailed(t, Uint32BE(), []byte{1, 2, 3})
+}
+
+func TestFloats(t *testing.T) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, math.Float32bits(1.5))
+	verifyBytes(t, Float32BE(), b[:4], float32(1.5))
+	binary.LittleEndian.PutUint32(b, math.Float32bits(-2.25))
+	verifyBytes(t, Float32LE(), b[:4], float32(-2.25))
+	binary.BigEndian.PutUint64(b, math.Float64bits(math.Pi))
+	verifyBytes(t, Float64BE(), b, math.Pi)
+	binary.LittleEndian.PutUint64(b, math.Float64bits(-math.E))
+	verifyBytes(t, Float64LE(), b, -math.E)
+}
+
+func TestVarints(t *testing.T) {
+	verifyBytes(t, Uvarint(), []byte{0x96, 0x01}, uint64(150))
+	verifyBytes(t, Uvarint(), binary.AppendUvarint(nil, math.MaxUint64), uint64(math.MaxUint64))
+	verifyBytesFailed(t, Uvarint(), []byte{0x96})
+	verifyBytesFailed(t, Uvarint(), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+	for _, v := range []int64{0, -1, 1, -150, math.MinInt64, math.MaxInt64} {
+		verifyBytes(t, Varint(), binary.AppendVarint(nil, v), v)
+	}
+	verifyBytes(t, Sleb128(), []byte{0x02}, int64(2))
+	verifyBytes(t, Sleb128(), []byte{0x7e}, int64(-2))
+	verifyBytes(t, Sleb128(), []byte{0xff, 0x00}, int64(127))
+	verifyBytes(t, Sleb128(), []byte{0xc0, 0xbb, 0x78}, int64(-123456))
+	verifyBytes(t, Sleb128(), []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}, int64(math.MinInt64))
+	verifyBytes(t, Sleb128(), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}, int64(math.MaxInt64))
+	verifyBytesFailed(t, Sleb128(), []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+	verifyBytesFailed(t, Sleb128(), []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7e})
+}
+
+func TestLengthPrefixed(t *testing.T) {
+	verifyBytes(t, LengthPrefixed(Byte()), []byte{3, 'a', 'b', 'c'}, []byte("abc"))
+	verifyBytes(t, LengthPrefixed(Uint16BE()), []byte{0, 0}, []byte{})
+	verifyBytes(t, LengthPrefixed(Uvarint()).Many(), []byte{1, 'a', 2, 'b', 'c'}, []any{[]byte("a"), []byte("bc")})
+	verifyBytesFailed(t, LengthPrefixed(Byte()), []byte{3, 'a'})
+	verifyBytesFailed(t, LengthPrefixed(Int8()), []byte{0xff})
+}
+
+func TestBinaryCombinators(t *testing.T) {
+	header := BytesEq('B', 'M').And(Uint32LE()).Map(func(r any) any {
+		return r.(Pair).Second
+	})
+	verifyBytes(t, header, []byte{'B', 'M', 0x10, 0, 0, 0}, uint32(16))
+	_, err := header.ParseBytes([]byte{'B', 'M', 0x10, 0})
+	assert.Equal(t, "parse error at offset 4: unexpected end of input", err.Error())
+	_, err = header.ParseBytes([]byte{'B', 'N'})
+	assert.Equal(t, "parse error at offset 0: expected 42 4d", err.Error())
+	verifyBytes(t, Ch('a').And(Byte()), []byte{'a', 0}, Pair{'a', byte(0)})
+}
+
+func TestBinaryIncremental(t *testing.T) {
+	ip := LengthPrefixed(Uint16BE()).IncrementalBytes()
+	ip.Feed([]byte{0})
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte{2, 0xff})
+	_, err := ip.Next()
+	assert.Equal(t, "parse error at offset 3: incomplete input", err.Error())
+	ip.Feed([]byte{0xfe, 0, 1})
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xff, 0xfe}, r)
+	assert.Equal(t, []byte{0, 1}, ip.Buffered())
+	_, err = ip.Next()
+	assert.Equal(t, &IncompleteError{Row: 1, Col: 7, Offset: 6, Binary: true}, err)
+	ip.Close()
+	_, err = ip.Next()
+	assert.Equal(t, "parse error at offset 6: unexpected end of input", err.Error())
+}
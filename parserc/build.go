@@ -0,0 +1,373 @@
+package parserc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 结构体标签语法：
+//
+//	@@          解析嵌套结构体并赋值给当前字段
+//	@Ident      捕获标识符并赋值给当前字段，同理还有@Int、@Float、@String
+//	@'abc'      捕获字面量并赋值给当前字段
+//	'abc'       匹配字面量，也可以写作"abc"
+//	@( ... )    捕获括号内匹配到的文本
+//	a b         顺序
+//	a | b       有序选择
+//	a* a+ a?    零次或多次、一次或多次、可选
+//
+// 每个终结符之前的空白字符会被自动跳过。
+
+type tagNodeKind int
+
+const (
+	tagSeq tagNodeKind = iota
+	tagAlt
+	tagRepeat
+	tagLiteral
+	tagTerminal
+	tagStruct
+	tagField
+)
+
+const tagFieldMarker = '\x00'
+
+type tagNode struct {
+	kind     tagNodeKind
+	text     string
+	op       rune
+	capture  bool
+	children []*tagNode
+}
+
+type capture struct {
+	field int
+	value any
+}
+
+type captures struct {
+	text string
+	list []capture
+}
+
+var (
+	tagWs      = Chs(' ', '\t', '\n', '\r').Many()
+	tagIdent   = Identifier(OneOf(Range('a', 'z'), Range('A', 'Z'), Ch('_')), OneOf(Range('a', 'z'), Range('A', 'Z'), Range('0', '9'), Ch('_'))).Surround(tagWs)
+	tagLit     = OneOf(quoted('\''), quoted('"')).Surround(tagWs)
+	tagExpr    = NewParser()
+	tagAtom    = OneOf(tagFieldRef, tagStructRef, tagCapture, tagPlain)
+	tagTerm    = tagAtom.And(Chs('*', '+', '?').Surround(tagWs).Opt(nil)).Map(buildTagTerm)
+	tagSeqExpr = tagTerm.Many1().Map(buildTagSeq)
+
+	tagFieldRef = Skip(Ch(tagFieldMarker)).And(Range('0', '9').Many1()).Surround(tagWs).Map(func(ds any) any {
+		return &tagNode{kind: tagField, text: joinResults(ds).(string)}
+	})
+	tagStructRef = Str("@@").Surround(tagWs).Map(func(any) any {
+		return &tagNode{kind: tagStruct, capture: true}
+	})
+	tagCapture = Skip(Ch('@')).And(tagPlain).Map(func(n any) any {
+		n.(*tagNode).capture = true
+		return n
+	})
+	tagPlain = OneOf(
+		tagLit.Map(func(s any) any {
+			return &tagNode{kind: tagLiteral, text: s.(string)}
+		}),
+		tagIdent.Map(func(s any) any {
+			return &tagNode{kind: tagTerminal, text: s.(string)}
+		}),
+		Skip(Ch('(').Surround(tagWs)).And(tagExpr).Skip(Ch(')').Surround(tagWs)),
+	)
+)
+
+func init() {
+	tagExpr.Set(Separate(Ch('|').Surround(tagWs), tagSeqExpr).Map(func(rs any) any {
+		if len(rs.([]any)) == 1 {
+			return rs.([]any)[0]
+		}
+		return &tagNode{kind: tagAlt, children: toTagNodes(rs)}
+	}))
+}
+
+func quoted(q rune) *Parser {
+	return Skip(Ch(q)).And(Not(q).Many()).Skip(Ch(q)).Map(func(cs any) any {
+		var sb strings.Builder
+		for _, c := range cs.([]any) {
+			sb.WriteRune(c.(rune))
+		}
+		return sb.String()
+	})
+}
+
+func toTagNodes(rs any) []*tagNode {
+	nodes := make([]*tagNode, 0)
+	for _, r := range rs.([]any) {
+		nodes = append(nodes, r.(*tagNode))
+	}
+	return nodes
+}
+
+func buildTagTerm(p any) any {
+	node := p.(Pair).First.(*tagNode)
+	if p.(Pair).Second == nil {
+		return node
+	}
+	return &tagNode{kind: tagRepeat, op: p.(Pair).Second.(rune), children: []*tagNode{node}}
+}
+
+func buildTagSeq(rs any) any {
+	if len(rs.([]any)) == 1 {
+		return rs.([]any)[0]
+	}
+	return &tagNode{kind: tagSeq, children: toTagNodes(rs)}
+}
+
+var terminals = map[string]*Parser{
+	"Ident": Identifier(OneOf(Range('a', 'z'), Range('A', 'Z'), Ch('_')), OneOf(Range('a', 'z'), Range('A', 'Z'), Range('0', '9'), Ch('_'))),
+	"Int":   Seq(Ch('-').Opt(""), Range('0', '9').Many1()).Map(joinResults),
+	"Float": Seq(Ch('-').Opt(""), Range('0', '9').Many1(), Seq(Ch('.'), Range('0', '9').Many1()).Opt(""),
+		Seq(Chs('e', 'E'), Chs('+', '-').Opt(""), Range('0', '9').Many1()).Opt("")).Map(joinResults),
+	"String": Skip(Ch('"')).And(Or(Skip(Ch('\\')).And(Any()), Not('"')).Many()).Skip(Ch('"')).Map(joinResults),
+}
+
+func joinResults(r any) any {
+	var sb strings.Builder
+	var write func(any)
+	write = func(r any) {
+		if rs, ok := r.([]any); ok {
+			for _, e := range rs {
+				write(e)
+			}
+			return
+		}
+		writeResult(&sb, r)
+	}
+	write(r)
+	return sb.String()
+}
+
+func mergeCaptures(rs any) any {
+	var sb strings.Builder
+	var list []capture
+	for _, r := range rs.([]any) {
+		sb.WriteString(r.(captures).text)
+		list = append(list, r.(captures).list...)
+	}
+	return captures{sb.String(), list}
+}
+
+type structBuilder struct {
+	parsers map[reflect.Type]*Parser
+}
+
+type structCompiler struct {
+	builder structBuilder
+	t       reflect.Type
+	field   int
+}
+
+// Build 根据结构体T的字段标签生成解析器，解析结果为*T
+func Build[T any]() (*Parser, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("parserc: %v is not a struct", t)
+	}
+	b := structBuilder{make(map[reflect.Type]*Parser)}
+	p, err := b.build(t)
+	if err != nil {
+		return nil, err
+	}
+	return p.Skip(tagWs), nil
+}
+
+// MustBuild 与Build相同，但在结构体标签有误时panic
+func MustBuild[T any]() *Parser {
+	p, err := Build[T]()
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (b structBuilder) build(t reflect.Type) (*Parser, error) {
+	if p, exist := b.parsers[t]; exist {
+		return p, nil
+	}
+	rule := NewParser().Named(t.Name())
+	b.parsers[t] = rule
+
+	// 所有字段的标签拼接为一条语法规则，字段之间插入标记以确定捕获结果所属的字段
+	var grammar strings.Builder
+	for k := 0; k < t.NumField(); k++ {
+		f := t.Field(k)
+		tag, exist := f.Tag.Lookup("parserc")
+		if !exist {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("parserc: field %v.%s is not exported", t, f.Name)
+		}
+		grammar.WriteString(fmt.Sprintf(" %c%d %s", tagFieldMarker, k, tag))
+	}
+	if grammar.Len() == 0 {
+		return nil, fmt.Errorf("parserc: struct %v has no grammar fields", t)
+	}
+	r, err := tagExpr.ParseToEnd(grammar.String())
+	if err != nil {
+		return nil, fmt.Errorf("parserc: invalid grammar of struct %v: %w", t, err)
+	}
+	c := structCompiler{b, t, -1}
+	p, err := c.compile(r.(*tagNode))
+	if err != nil {
+		return nil, fmt.Errorf("parserc: invalid tag of field %v.%s: %w", t, t.Field(c.field).Name, err)
+	}
+	rule.Set(&Parser{kind: "Map", children: []*Parser{p}, parse: func(input Input) (ParseResult, error) {
+		r, err := p.run(input)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		v := reflect.New(t)
+		for _, c := range r.Result.(captures).list {
+			if err := assign(v.Elem().Field(c.field), c.value); err != nil {
+				return emptyParseResult, parseError(input, err.Error())
+			}
+		}
+		return ParseResult{v.Interface(), r.Remain}, nil
+	}})
+	return rule, nil
+}
+
+func (c *structCompiler) compile(n *tagNode) (*Parser, error) {
+	var p *Parser
+	switch n.kind {
+	case tagField:
+		c.field, _ = strconv.Atoi(n.text)
+		return Succeed(captures{}), nil
+	case tagSeq, tagAlt:
+		parsers := make([]*Parser, 0)
+		for _, child := range n.children {
+			cp, err := c.compile(child)
+			if err != nil {
+				return nil, err
+			}
+			parsers = append(parsers, cp)
+		}
+		if n.kind == tagSeq {
+			p = Seq(parsers...).Map(mergeCaptures)
+		} else {
+			p = OneOf(parsers[0], parsers[1], parsers[2:]...)
+		}
+	case tagRepeat:
+		cp, err := c.compile(n.children[0])
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case '*':
+			p = cp.Many().Map(mergeCaptures)
+		case '+':
+			p = cp.Many1().Map(mergeCaptures)
+		default:
+			p = cp.Opt(captures{})
+		}
+	case tagLiteral:
+		lit := Str(n.text)
+		if n.text != "" && isIdentRune([]rune(n.text)[0]) {
+			lit = Keyword(n.text)
+		}
+		p = Skip(tagWs).And(lit).Map(func(s any) any {
+			return captures{text: s.(string)}
+		})
+	case tagTerminal:
+		t, exist := terminals[n.text]
+		if !exist {
+			return nil, fmt.Errorf("unknown terminal %s", n.text)
+		}
+		p = Skip(tagWs).And(t).Map(func(s any) any {
+			return captures{text: s.(string)}
+		})
+	case tagStruct:
+		t := c.t.Field(c.field).Type
+		for t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, errors.New("@@ requires a struct field")
+		}
+		sp, err := c.builder.build(t)
+		if err != nil {
+			return nil, err
+		}
+		field := c.field
+		return sp.Map(func(v any) any {
+			return captures{list: []capture{{field, reflect.ValueOf(v)}}}
+		}), nil
+	}
+	if !n.capture {
+		return p, nil
+	}
+	field := c.field
+	return p.Map(func(r any) any {
+		text := r.(captures).text
+		return captures{text, append(r.(captures).list, capture{field, text})}
+	}), nil
+}
+
+func assign(field reflect.Value, value any) error {
+	if v, ok := value.(reflect.Value); ok {
+		return assignStruct(field, v)
+	}
+	text := value.(string)
+	switch field.Kind() {
+	case reflect.Slice:
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := assign(elem, text); err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, elem))
+	case reflect.String:
+		field.SetString(field.String() + text)
+	case reflect.Bool:
+		field.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %s", text)
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %s", text)
+		}
+		field.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float %s", text)
+		}
+		field.SetFloat(v)
+	default:
+		return fmt.Errorf("cannot capture into field of type %v", field.Type())
+	}
+	return nil
+}
+
+func assignStruct(field reflect.Value, v reflect.Value) error {
+	switch {
+	case field.Kind() == reflect.Slice && field.Type().Elem() == v.Type():
+		field.Set(reflect.Append(field, v))
+	case field.Kind() == reflect.Slice && field.Type().Elem() == v.Type().Elem():
+		field.Set(reflect.Append(field, v.Elem()))
+	case field.Type() == v.Type():
+		field.Set(v)
+	case field.Type() == v.Type().Elem():
+		field.Set(v.Elem())
+	default:
+		return fmt.Errorf("cannot capture %v into field of type %v", v.Type(), field.Type())
+	}
+	return nil
+}
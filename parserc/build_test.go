@@ -0,0 +1,92 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type buildEntry struct {
+	Key   string      `parserc:"@Ident '='"`
+	Value *buildValue `parserc:"@@"`
+}
+
+type buildValue struct {
+	Str  string        `parserc:"  @String"`
+	Num  float64       `parserc:"| @Float"`
+	Bool bool          `parserc:"| @'true' | 'false'"`
+	List []*buildValue `parserc:"| '[' (@@ (',' @@)*)? ']'"`
+}
+
+type buildConfig struct {
+	Name    string       `parserc:"'config' @Ident '{'"`
+	Entries []buildEntry `parserc:"@@* '}'"`
+}
+
+func TestBuild(t *testing.T) {
+	p, err := Build[buildConfig]()
+	assert.Nil(t, err)
+
+	r, err := p.ParseToEnd(`
+	config server {
+		host = "localhost"
+		port = 8080
+		debug = true
+		verbose = false
+		tags = ["a", 1.5, []]
+	}
+	`)
+	assert.Nil(t, err)
+	assert.Equal(t, &buildConfig{
+		Name: "server",
+		Entries: []buildEntry{
+			{"host", &buildValue{Str: "localhost"}},
+			{"port", &buildValue{Num: 8080}},
+			{"debug", &buildValue{Bool: true}},
+			{"verbose", &buildValue{}},
+			{"tags", &buildValue{List: []*buildValue{{Str: "a"}, {Num: 1.5}, {}}}},
+		},
+	}, r)
+
+	verifyFailed(t, p, "config server {")
+	verifyFailed(t, p, "config server { a = }")
+	verifyFailed(t, p, "configserver {}")
+}
+
+type buildCapture struct {
+	Sign  string `parserc:"@('+' | '-')?"`
+	Value int    `parserc:"@Int"`
+	Unit  string `parserc:"@('k' 'm')?"`
+}
+
+func TestBuildCapture(t *testing.T) {
+	p := MustBuild[buildCapture]()
+	verifySuccess(t, p, "-12", &buildCapture{"-", 12, ""})
+	verifySuccess(t, p, "+ 3 k m", &buildCapture{"+", 3, "km"})
+	verifySuccess(t, p, "7", &buildCapture{"", 7, ""})
+}
+
+type buildBadTag struct {
+	A string `parserc:"@Ident ("`
+}
+
+type buildUnknownTerminal struct {
+	A string `parserc:"@Number"`
+}
+
+type buildBadCapture struct {
+	A int `parserc:"@@"`
+}
+
+func TestBuildError(t *testing.T) {
+	_, err := Build[buildBadTag]()
+	assert.NotNil(t, err)
+	_, err = Build[buildUnknownTerminal]()
+	assert.EqualError(t, err, "parserc: invalid tag of field parserc.buildUnknownTerminal.A: unknown terminal Number")
+	_, err = Build[buildBadCapture]()
+	assert.NotNil(t, err)
+	_, err = Build[int]()
+	assert.NotNil(t, err)
+	assert.Panics(t, func() {
+		MustBuild[buildBadTag]()
+	})
+}
@@ -0,0 +1,48 @@
+package parserc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dot 将当前解析器的组合子图导出为Graphviz DOT格式，NewParser与Set形成的环也会被导出
+func (p *Parser) Dot() string {
+	ids := make(map[*Parser]int)
+	var nodes, edges strings.Builder
+	var visit func(p *Parser) int
+	visit = func(p *Parser) int {
+		if id, exist := ids[p]; exist {
+			return id
+		}
+		id := len(ids)
+		ids[p] = id
+		label := p.label()
+		attrs := ""
+		if p.name != "" {
+			label = p.name + ": " + label
+			attrs = ", style=bold"
+		}
+		if p.kind == "NewParser" {
+			attrs += ", shape=ellipse"
+		}
+		nodes.WriteString(fmt.Sprintf("\tn%d [label=%s%s];\n", id, strconv.Quote(label), attrs))
+		for k, c := range p.children {
+			cid := visit(c)
+			if len(p.children) > 1 {
+				edges.WriteString(fmt.Sprintf("\tn%d -> n%d [label=\"%d\"];\n", id, cid, k))
+			} else {
+				edges.WriteString(fmt.Sprintf("\tn%d -> n%d;\n", id, cid))
+			}
+		}
+		return id
+	}
+	visit(p)
+	var sb strings.Builder
+	sb.WriteString("digraph parser {\n")
+	sb.WriteString("\tnode [shape=box, fontname=monospace];\n")
+	sb.WriteString(nodes.String())
+	sb.WriteString(edges.String())
+	sb.WriteString("}\n")
+	return sb.String()
+}
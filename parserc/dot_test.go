@@ -0,0 +1,48 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDot(t *testing.T) {
+	assert.Equal(t, `digraph parser {
+	node [shape=box, fontname=monospace];
+	n0 [label="OneOf"];
+	n1 [label="Str(\"abc\")"];
+	n2 [label="Range('0', '9')"];
+	n3 [label="Chs('a', 'b')"];
+	n0 -> n1 [label="0"];
+	n0 -> n2 [label="1"];
+	n0 -> n3 [label="2"];
+}
+`, OneOf(Str("abc"), Range('0', '9'), Chs('a', 'b')).Dot())
+}
+
+func TestDotCycle(t *testing.T) {
+	list := NewParser().Named("list")
+	list.Set(Skip(Ch('[')).And(list.Many()).Skip(Ch(']')))
+	assert.Equal(t, `digraph parser {
+	node [shape=box, fontname=monospace];
+	n0 [label="list: NewParser", style=bold, shape=ellipse];
+	n1 [label="SkipSecond"];
+	n2 [label="SkipFirst"];
+	n3 [label="Ch('[')"];
+	n4 [label="Many"];
+	n5 [label="Ch(']')"];
+	n2 -> n3 [label="0"];
+	n4 -> n0;
+	n2 -> n4 [label="1"];
+	n1 -> n2 [label="0"];
+	n1 -> n5 [label="1"];
+	n0 -> n1;
+}
+`, list.Dot())
+}
+
+func TestDotArgs(t *testing.T) {
+	assert.Contains(t, Ch('a').Opt(nil).Dot(), `[label="Opt(nil)"]`)
+	assert.Contains(t, Ch('a').Repeat(1, 3).Dot(), `[label="Repeat(1, 3)"]`)
+	assert.Contains(t, Keyword("if").Dot(), `[label="Keyword(\"if\")"]`)
+	assert.Contains(t, Ch('\n').Dot(), `[label="Ch('\\n')"]`)
+}
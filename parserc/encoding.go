@@ -0,0 +1,136 @@
+package parserc
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding 输入的字符编码
+type Encoding int
+
+const (
+	EncodingUTF8    Encoding = iota // UTF-8
+	EncodingUTF16LE                 // 小端序UTF-16
+	EncodingUTF16BE                 // 大端序UTF-16
+)
+
+// EncodingError 输入包含无效的编码
+type EncodingError struct {
+	Name   string // 输入的名称，没有名称时为空字符串
+	Offset int    // 无效编码在原始数据中的字节偏移
+	Row    int    // 无效编码在解码后文本中的行号
+	Col    int    // 无效编码在解码后文本中的列号
+	Msg    string // 错误描述
+}
+
+func (e *EncodingError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("encoding error in %s at row %d, col %d (offset %d): %s", e.Name, e.Row, e.Col, e.Offset, e.Msg)
+	}
+	return fmt.Sprintf("encoding error at row %d, col %d (offset %d): %s", e.Row, e.Col, e.Offset, e.Msg)
+}
+
+// DetectEncoding 根据BOM检测b的编码，返回编码与BOM的字节数，没有BOM时视为UTF-8
+func DetectEncoding(b []byte) (Encoding, int) {
+	switch {
+	case len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf:
+		return EncodingUTF8, 3
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe:
+		return EncodingUTF16LE, 2
+	case len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff:
+		return EncodingUTF16BE, 2
+	}
+	return EncodingUTF8, 0
+}
+
+// decodeRune 从b的开头以指定编码解码一个字符，返回字符与占用的字节数，编码无效时返回错误描述
+func decodeRune(enc Encoding, b []byte) (rune, int, string) {
+	if enc == EncodingUTF8 {
+		c, size := utf8.DecodeRune(b)
+		if c == utf8.RuneError && size <= 1 {
+			return utf8.RuneError, 1, "invalid UTF-8 sequence"
+		}
+		return c, size, ""
+	}
+	unit := func(k int) rune {
+		if enc == EncodingUTF16LE {
+			return rune(b[k]) | rune(b[k+1])<<8
+		}
+		return rune(b[k])<<8 | rune(b[k+1])
+	}
+	if len(b) < 2 {
+		return utf8.RuneError, len(b), "truncated UTF-16 code unit"
+	}
+	u := unit(0)
+	if !utf16.IsSurrogate(u) {
+		return u, 2, ""
+	}
+	if u < 0xdc00 && len(b) >= 4 {
+		if c := utf16.DecodeRune(u, unit(2)); c != utf8.RuneError {
+			return c, 4, ""
+		}
+	}
+	return utf8.RuneError, 2, "unpaired UTF-16 surrogate"
+}
+
+// decodeBytes 去除BOM并将b解码为UTF-8文本，replace为true时将无效编码替换为U+FFFD，否则返回EncodingError
+func decodeBytes(name string, b []byte, replace bool) (string, error) {
+	enc, bom := DetectEncoding(b)
+	if enc == EncodingUTF8 && utf8.Valid(b[bom:]) {
+		return string(b[bom:]), nil
+	}
+	var sb strings.Builder
+	row, col := 1, 1
+	for k := bom; k < len(b); {
+		c, size, msg := decodeRune(enc, b[k:])
+		if msg != "" && !replace {
+			return "", &EncodingError{name, k, row, col, msg}
+		}
+		sb.WriteRune(c)
+		if c == '\n' {
+			row, col = row+1, 1
+		} else {
+			col++
+		}
+		k += size
+	}
+	return sb.String(), nil
+}
+
+// validateUTF8 检查s是否为有效的UTF-8文本，无效时返回第一处无效编码的EncodingError
+func validateUTF8(s string) error {
+	if utf8.ValidString(s) {
+		return nil
+	}
+	row, col := 1, 1
+	for k, c := range s {
+		if c == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[k:]); size <= 1 {
+				return &EncodingError{"", k, row, col, "invalid UTF-8 sequence"}
+			}
+		}
+		if c == '\n' {
+			row, col = row+1, 1
+		} else {
+			col++
+		}
+	}
+	return nil
+}
+
+// DecodeInput 检测并去除BOM，将UTF-16转码后创建输入流，输入包含无效的编码时返回EncodingError
+func DecodeInput(b []byte) (Input, error) {
+	s, err := decodeBytes("", b, false)
+	if err != nil {
+		return Input{}, err
+	}
+	return CreateInput(s), nil
+}
+
+// DecodeInputReplace 与DecodeInput相同，但将无效的编码替换为U+FFFD
+func DecodeInputReplace(b []byte) Input {
+	s, _ := decodeBytes("", b, true)
+	return CreateInput(s)
+}
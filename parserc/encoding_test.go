@@ -0,0 +1,133 @@
+package parserc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unicode/utf8"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	b := []byte{0xff, 0xfe}
+	if bigEndian {
+		b = []byte{0xfe, 0xff}
+	}
+	for _, c := range s {
+		units := []rune{c}
+		if c > 0xffff {
+			c -= 0x10000
+			units = []rune{0xd800 + c>>10, 0xdc00 + c&0x3ff}
+		}
+		for _, u := range units {
+			if bigEndian {
+				b = append(b, byte(u>>8), byte(u))
+			} else {
+				b = append(b, byte(u), byte(u>>8))
+			}
+		}
+	}
+	return b
+}
+
+func verifyDecoded(t *testing.T, b []byte, expected string) {
+	input, err := DecodeInput(b)
+	assert.Nil(t, err)
+	r, err := Any().Many().Map(func(r any) any {
+		var sb strings.Builder
+		for _, c := range r.([]any) {
+			sb.WriteRune(c.(rune))
+		}
+		return sb.String()
+	}).ParseInput(input)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, r)
+}
+
+func TestDetectEncoding(t *testing.T) {
+	enc, bom := DetectEncoding([]byte("\xef\xbb\xbfa"))
+	assert.Equal(t, [2]int{int(EncodingUTF8), 3}, [2]int{int(enc), bom})
+	enc, bom = DetectEncoding([]byte{0xff, 0xfe, 'a', 0})
+	assert.Equal(t, [2]int{int(EncodingUTF16LE), 2}, [2]int{int(enc), bom})
+	enc, bom = DetectEncoding([]byte{0xfe, 0xff, 0, 'a'})
+	assert.Equal(t, [2]int{int(EncodingUTF16BE), 2}, [2]int{int(enc), bom})
+	enc, bom = DetectEncoding([]byte("a"))
+	assert.Equal(t, [2]int{int(EncodingUTF8), 0}, [2]int{int(enc), bom})
+}
+
+func TestDecodeInput(t *testing.T) {
+	verifyDecoded(t, []byte("\xef\xbb\xbf你好"), "你好")
+	verifyDecoded(t, []byte("plain"), "plain")
+	verifyDecoded(t, utf16Bytes("a你😀\n", false), "a你😀\n")
+	verifyDecoded(t, utf16Bytes("a你😀\n", true), "a你😀\n")
+	verifyDecoded(t, []byte{}, "")
+
+	_, err := DecodeInput([]byte("ab\nc\xffd"))
+	var encErr *EncodingError
+	assert.True(t, errors.As(err, &encErr))
+	assert.Equal(t, "encoding error at row 2, col 2 (offset 4): invalid UTF-8 sequence", err.Error())
+	_, err = DecodeInput(append(utf16Bytes("ab", false), 0x00, 0xd8, 'c', 0))
+	assert.Equal(t, "encoding error at row 1, col 3 (offset 6): unpaired UTF-16 surrogate", err.Error())
+	_, err = DecodeInput(append(utf16Bytes("a", true), 'b'))
+	assert.Equal(t, "encoding error at row 1, col 2 (offset 4): truncated UTF-16 code unit", err.Error())
+
+	input := DecodeInputReplace([]byte("a\xffb"))
+	r, err := Str("a�b").ParseInput(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "a�b", r)
+}
+
+func TestParseToEndEncoding(t *testing.T) {
+	p := Not('x').Many()
+	_, err := p.ParseToEnd("ab\n c\xffd")
+	assert.Equal(t, &EncodingError{"", 5, 2, 3, "invalid UTF-8 sequence"}, err)
+	_, err = p.ParseToEndContext(context.Background(), "\xc3")
+	assert.Equal(t, "encoding error at row 1, col 1 (offset 0): invalid UTF-8 sequence", err.Error())
+	r, err := p.ParseToEnd("a你😀")
+	assert.Nil(t, err)
+	assert.Equal(t, []any{'a', '你', '😀'}, r)
+	// CreateInput不检查编码，无效的编码按U+FFFD解析
+	r, err = p.ParseInput(CreateInput("a\xff"))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{'a', utf8.RuneError}, r)
+}
+
+func TestParseReaderEncoding(t *testing.T) {
+	r, err := Str("a你😀").ParseReader(strings.NewReader(string(utf16Bytes("a你😀", true))))
+	assert.Nil(t, err)
+	assert.Equal(t, "a你😀", r)
+	r, err = Str("ab").ParseReader(strings.NewReader("\xef\xbb\xbfab"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", r)
+	_, err = Any().Many().ParseReader(strings.NewReader("a\nbc\x80"))
+	assert.Equal(t, "encoding error at row 2, col 3 (offset 4): invalid UTF-8 sequence", err.Error())
+	_, err = Any().Many().ParseReader(strings.NewReader(string(append(utf16Bytes("a", false), 0x00, 0xdc))))
+	assert.Equal(t, "encoding error at row 1, col 2 (offset 4): unpaired UTF-16 surrogate", err.Error())
+}
+
+func TestParseFileEncoding(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "utf16.txt")
+	assert.Nil(t, os.WriteFile(path, utf16Bytes("hello", false), 0644))
+	r, err := Str("hello").ParseFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", r)
+	bad := filepath.Join(dir, "bad.txt")
+	assert.Nil(t, os.WriteFile(bad, []byte("\xc3("), 0644))
+	_, err = Any().Many().ParseFile(bad)
+	assert.Equal(t, "encoding error in "+bad+" at row 1, col 1 (offset 0): invalid UTF-8 sequence", err.Error())
+
+	fsys := fstest.MapFS{"a": {Data: utf16Bytes("x", true)}, "b": {Data: []byte("\xff")}}
+	p := IncludeFS(fsys, Any().Map(func(r any) any {
+		return string(r.(rune))
+	}), Ch('x'))
+	r, err = p.ParseToEnd("a")
+	assert.Nil(t, err)
+	assert.Equal(t, 'x', r)
+	_, err = p.ParseToEnd("b")
+	assert.Equal(t, "encoding error in b at row 1, col 1 (offset 0): invalid UTF-8 sequence", err.Error())
+}
@@ -0,0 +1,282 @@
+package parserc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type exprKind int
+
+const (
+	exprEmpty    exprKind = iota // 空
+	exprTerminal                 // 字面量
+	exprClass                    // 字符集
+	exprSpecial                  // 无法用EBNF表示的解析器
+	exprRef                      // 规则引用
+	exprSeq                      // 顺序
+	exprChoice                   // 有序选择
+	exprRepeat                   // 重复
+)
+
+// expr 由解析器转换得到的语法表达式，用于导出EBNF与铁路图
+type expr struct {
+	kind  exprKind
+	text  string
+	fold  bool
+	items []*expr
+	min   int
+	max   int // 小于0表示不限次数
+}
+
+type exportRule struct {
+	name string
+	expr *expr
+}
+
+type exporter struct {
+	names map[*Parser]string
+	queue []*Parser
+	auto  int
+}
+
+// exportRules 从p出发遍历解析器图，将p以及所有命名解析器、NewParser创建的解析器转换为规则
+func exportRules(p *Parser) []exportRule {
+	e := &exporter{names: make(map[*Parser]string)}
+	e.ruleName(p)
+	rules := make([]exportRule, 0)
+	for k := 0; k < len(e.queue); k++ {
+		r := e.queue[k]
+		rules = append(rules, exportRule{e.names[r], e.convert(r, true)})
+	}
+	return rules
+}
+
+func isRule(p *Parser) bool {
+	return p.name != "" || p.kind == "NewParser"
+}
+
+func (e *exporter) ruleName(p *Parser) string {
+	if name, exist := e.names[p]; exist {
+		return name
+	}
+	name := p.name
+	if name == "" {
+		e.auto++
+		name = fmt.Sprintf("rule%d", e.auto)
+	}
+	e.names[p] = name
+	e.queue = append(e.queue, p)
+	return name
+}
+
+func (e *exporter) convertAll(ps []*Parser) []*expr {
+	items := make([]*expr, 0, len(ps))
+	for _, p := range ps {
+		items = append(items, e.convert(p, false))
+	}
+	return items
+}
+
+func seqExpr(items ...*expr) *expr {
+	return &expr{kind: exprSeq, items: items}
+}
+
+func choiceExpr(items ...*expr) *expr {
+	return &expr{kind: exprChoice, items: items}
+}
+
+func repeatExpr(item *expr, min int, max int) *expr {
+	return &expr{kind: exprRepeat, items: []*expr{item}, min: min, max: max}
+}
+
+func (e *exporter) convert(p *Parser, top bool) *expr {
+	if !top && isRule(p) {
+		return &expr{kind: exprRef, text: e.ruleName(p)}
+	}
+	children := p.children
+	switch p.kind {
+	case "NewParser":
+		if len(children) == 0 {
+			return &expr{kind: exprSpecial, text: "unset"}
+		}
+		return e.convert(children[0], false)
+	case "Succeed":
+		return &expr{kind: exprEmpty}
+	case "Fail":
+		return &expr{kind: exprSpecial, text: "fail"}
+	case "Any":
+		return &expr{kind: exprClass, text: "."}
+	case "Ch", "ChFold", "ChFoldRaw":
+		return &expr{kind: exprTerminal, text: string(p.args[0].(rune)), fold: p.kind != "Ch"}
+	case "Str", "StrFold", "StrFoldRaw", "Keyword", "KeywordFold":
+		fold := p.kind != "Str" && p.kind != "Keyword"
+		return &expr{kind: exprTerminal, text: p.args[0].(string), fold: fold}
+	case "Chs":
+		return &expr{kind: exprClass, text: classText(false, p.args)}
+	case "Not":
+		return &expr{kind: exprClass, text: classText(true, p.args)}
+	case "Range":
+		return &expr{kind: exprClass, text: "[" + classRune(p.args[0].(rune)) + "-" + classRune(p.args[1].(rune)) + "]"}
+	case "CharClass":
+		var sb strings.Builder
+		for k := 1; k < len(p.args); k += 2 {
+			sb.WriteString(classRune(p.args[k].(rune)))
+			if p.args[k] != p.args[k+1] {
+				sb.WriteString("-" + classRune(p.args[k+1].(rune)))
+			}
+		}
+		if p.args[0].(bool) {
+			return &expr{kind: exprClass, text: "[^" + sb.String() + "]"}
+		}
+		return &expr{kind: exprClass, text: "[" + sb.String() + "]"}
+	case "Regex":
+		return &expr{kind: exprSpecial, text: "/" + p.args[0].(string) + "/"}
+	case "Literals", "LiteralsMap":
+		items := make([]*expr, 0)
+		for _, w := range p.args {
+			items = append(items, &expr{kind: exprTerminal, text: w.(string)})
+		}
+		return choiceExpr(items...)
+	case "Identifier", "IdentifierFold":
+		return seqExpr(e.convert(children[0], false), repeatExpr(e.convert(children[1], false), 0, -1))
+	case "And", "Seq", "SkipFirst", "SkipSecond":
+		return seqExpr(e.convertAll(children)...)
+	case "Surround":
+		around := e.convert(children[1], false)
+		return seqExpr(around, e.convert(children[0], false), around)
+	case "Or", "OneOf":
+		items := make([]*expr, 0)
+		for _, item := range e.convertAll(children) {
+			if item.kind == exprChoice {
+				items = append(items, item.items...)
+			} else {
+				items = append(items, item)
+			}
+		}
+		return choiceExpr(items...)
+	case "Peek":
+		return choiceExpr(e.convert(children[1], false), e.convert(children[2], false))
+	case "Many", "ManyUntil":
+		return repeatExpr(e.convert(children[0], false), 0, -1)
+	case "Many1":
+		return repeatExpr(e.convert(children[0], false), 1, -1)
+	case "Opt":
+		return repeatExpr(e.convert(children[0], false), 0, 1)
+	case "Times":
+		return repeatExpr(e.convert(children[0], false), p.args[0].(int), p.args[0].(int))
+	case "AtLeast":
+		return repeatExpr(e.convert(children[0], false), p.args[0].(int), -1)
+	case "AtMost":
+		return repeatExpr(e.convert(children[0], false), 0, p.args[0].(int))
+	case "Repeat":
+		return repeatExpr(e.convert(children[0], false), p.args[0].(int), p.args[1].(int))
+	case "Separate", "SepBy", "SepByKeep", "SepBy1", "SepBy1Keep":
+		d, item := e.convert(children[0], false), e.convert(children[1], false)
+		r := seqExpr(item, repeatExpr(seqExpr(d, item), 0, -1))
+		if p.kind == "SepBy" || p.kind == "SepByKeep" {
+			return repeatExpr(r, 0, 1)
+		}
+		return r
+	case "SepEndBy", "SepEndByKeep":
+		d, item := e.convert(children[0], false), e.convert(children[1], false)
+		return repeatExpr(seqExpr(item, repeatExpr(seqExpr(d, item), 0, -1), repeatExpr(d, 0, 1)), 0, 1)
+	case "EndBy", "EndByKeep":
+		d, item := e.convert(children[0], false), e.convert(children[1], false)
+		return repeatExpr(seqExpr(item, d), 0, -1)
+	case "Permutation":
+		return repeatExpr(choiceExpr(e.convertAll(children)...), 0, len(children))
+	}
+	if len(children) == 1 {
+		return e.convert(children[0], false)
+	}
+	return &expr{kind: exprSpecial, text: p.kind}
+}
+
+func classRune(c rune) string {
+	switch c {
+	case ']', '[', '^', '-', '\\':
+		return `\` + string(c)
+	}
+	q := strconv.QuoteRune(c)
+	return q[1 : len(q)-1]
+}
+
+func classText(negate bool, cs []any) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	if negate {
+		sb.WriteString("^")
+	}
+	for _, c := range cs {
+		sb.WriteString(classRune(c.(rune)))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+const (
+	precChoice = iota
+	precSeq
+	precPostfix
+)
+
+func (x *expr) ebnf(prec int) string {
+	var s string
+	var p int
+	switch x.kind {
+	case exprEmpty:
+		return "()"
+	case exprTerminal:
+		s, p = strconv.Quote(x.text), precPostfix
+		if x.fold {
+			s += "i"
+		}
+	case exprClass, exprRef:
+		s, p = x.text, precPostfix
+	case exprSpecial:
+		s, p = "? "+x.text+" ?", precPostfix
+	case exprSeq:
+		parts := make([]string, 0)
+		for _, item := range x.items {
+			parts = append(parts, item.ebnf(precSeq))
+		}
+		s, p = strings.Join(parts, " "), precSeq
+	case exprChoice:
+		parts := make([]string, 0)
+		for _, item := range x.items {
+			parts = append(parts, item.ebnf(precChoice+1))
+		}
+		s, p = strings.Join(parts, " | "), precChoice
+	case exprRepeat:
+		item := x.items[0].ebnf(precPostfix)
+		switch {
+		case x.min == 0 && x.max < 0:
+			s = item + "*"
+		case x.min == 1 && x.max < 0:
+			s = item + "+"
+		case x.min == 0 && x.max == 1:
+			s = item + "?"
+		case x.max < 0:
+			s = fmt.Sprintf("%s{%d,}", item, x.min)
+		case x.min == x.max:
+			s = fmt.Sprintf("%s{%d}", item, x.min)
+		default:
+			s = fmt.Sprintf("%s{%d,%d}", item, x.min, x.max)
+		}
+		p = precPostfix
+	}
+	if p < prec {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// EBNF 将当前解析器及其引用的规则导出为EBNF文本
+func (p *Parser) EBNF() string {
+	var sb strings.Builder
+	for _, r := range exportRules(p) {
+		sb.WriteString(fmt.Sprintf("%s ::= %s\n", r.name, r.expr.ebnf(precChoice)))
+	}
+	return sb.String()
+}
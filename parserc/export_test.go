@@ -0,0 +1,53 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEBNF(t *testing.T) {
+	ws := Chs(' ', '\t').Many()
+	digit := Range('0', '9')
+	expr := NewParser().Named("expr")
+	number := digit.Many1().Named("number")
+	fact := OneOf(number, Skip(Ch('(')).And(expr).Skip(Ch(')'))).Named("fact")
+	term := fact.And(Chs('*', '/').Surround(ws).And(fact).Many()).Named("term")
+	expr.Set(term.And(Or(Ch('+'), Ch('-')).Surround(ws).And(term).Many()))
+
+	assert.Equal(t, `expr ::= term ([ \t]* ("+" | "-") [ \t]* term)*
+term ::= fact ([ \t]* [*/] [ \t]* fact)*
+fact ::= number | "(" expr ")"
+number ::= [0-9]+
+`, expr.EBNF())
+}
+
+func TestEBNFPrimitives(t *testing.T) {
+	assert.Equal(t, "rule1 ::= . [^\\]] \"a\"i \"select\"i \"if\"\n", Seq(Any(), Not(']'), ChFold('a'), StrFold("select"), Keyword("if")).EBNF())
+	assert.Equal(t, "rule1 ::= \"<\" | \"<=\" | \"<<\"\n", Literals("<", "<=", "<<").EBNF())
+	assert.Equal(t, "rule1 ::= [a-z]{4} [a-z]{2,} [a-z]{0,3} [a-z]{1,3}\n",
+		Seq(Range('a', 'z').Times(4), Range('a', 'z').AtLeast(2), Range('a', 'z').AtMost(3), Range('a', 'z').Repeat(1, 3)).EBNF())
+	assert.Equal(t, "rule1 ::= ([a-z] (\",\" [a-z])*)?\n", SepBy(Ch(','), Range('a', 'z')).EBNF())
+	assert.Equal(t, "rule1 ::= ([a-z] \";\")*\n", EndBy(Ch(';'), Range('a', 'z')).EBNF())
+	assert.Equal(t, "rule1 ::= [a-z] ([a-z] | [0-9])*\n", Identifier(Range('a', 'z'), Or(Range('a', 'z'), Range('0', '9'))).EBNF())
+	assert.Equal(t, "rule1 ::= \"a\"?\n", Ch('a').Opt(nil).Map(func(r any) any { return r }).EBNF())
+}
+
+func TestEBNFAutoName(t *testing.T) {
+	p := NewParser()
+	list := Skip(Ch('[')).And(SepBy(Ch(','), p)).Skip(Ch(']'))
+	p.Set(Or(Range('0', '9'), list))
+	assert.Equal(t, `rule1 ::= "[" (rule2 ("," rule2)*)? "]"
+rule2 ::= [0-9] | "[" (rule2 ("," rule2)*)? "]"
+`, list.EBNF())
+}
+
+func TestEBNFGrammar(t *testing.T) {
+	g, err := CompileGrammar(`
+	list  <- '[' items? ']'
+	items <- [0-9] (',' [0-9])*
+	`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `list ::= "[" items? "]"
+items ::= [0-9] ("," [0-9])*
+`, g.Start().EBNF())
+}
@@ -0,0 +1,257 @@
+package parserc
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// runeSet 字符集合，由按升序排列且互不相交的闭区间组成，两两一组
+type runeSet []rune
+
+func (s runeSet) contains(c rune) bool {
+	k := sort.Search(len(s)/2, func(k int) bool {
+		return s[2*k+1] >= c
+	})
+	return k < len(s)/2 && s[2*k] <= c
+}
+
+// union 合并两个字符集合
+func (s runeSet) union(other runeSet) runeSet {
+	if len(other) == 0 {
+		return s
+	}
+	ranges := make([][2]rune, 0, (len(s)+len(other))/2)
+	for _, set := range []runeSet{s, other} {
+		for k := 0; k+1 < len(set); k += 2 {
+			ranges = append(ranges, [2]rune{set[k], set[k+1]})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+	result := make(runeSet, 0, len(ranges)*2)
+	for _, r := range ranges {
+		if n := len(result); n > 0 && r[0] <= result[n-1]+1 {
+			if r[1] > result[n-1] {
+				result[n-1] = r[1]
+			}
+			continue
+		}
+		result = append(result, r[0], r[1])
+	}
+	return result
+}
+
+func singleRune(c rune, fold bool) runeSet {
+	s := runeSet{c, c}
+	if fold {
+		for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+			s = s.union(runeSet{f, f})
+		}
+	}
+	return s
+}
+
+// firstString 字符串第一个字符组成的集合，空字符串返回nil
+func firstString(s string, fold bool) runeSet {
+	if s == "" {
+		return nil
+	}
+	c, _ := utf8.DecodeRuneInString(s)
+	return singleRune(c, fold)
+}
+
+// firstInfo 解析器的FIRST集，即解析成功时可能消耗的第一个字符
+type firstInfo struct {
+	set      runeSet
+	nullable bool // 是否可能不消耗任何字符而成功
+	known    bool // 是否能够分析，无法分析的解析器在任何字符上都可能成功
+}
+
+var unknownFirst = firstInfo{known: false}
+
+// firstAnalyzer 计算解析器图中各解析器的FIRST集，递归引用的解析器视为无法分析
+type firstAnalyzer struct {
+	cache    map[*Parser]firstInfo
+	visiting map[*Parser]bool
+}
+
+func (a *firstAnalyzer) first(p *Parser) firstInfo {
+	if info, exist := a.cache[p]; exist {
+		return info
+	}
+	if a.visiting[p] {
+		return unknownFirst
+	}
+	a.visiting[p] = true
+	info := a.analyze(p)
+	delete(a.visiting, p)
+	a.cache[p] = info
+	return info
+}
+
+// sequence 依次连接的解析器的FIRST集
+func (a *firstAnalyzer) sequence(parsers ...*Parser) firstInfo {
+	info := firstInfo{nullable: true, known: true}
+	for _, p := range parsers {
+		f := a.first(p)
+		if !f.known {
+			return unknownFirst
+		}
+		info.set = info.set.union(f.set)
+		if !f.nullable {
+			info.nullable = false
+			break
+		}
+	}
+	return info
+}
+
+// optional 可能不消耗任何字符而成功的解析器的FIRST集
+func (a *firstAnalyzer) optional(p *Parser) firstInfo {
+	info := a.first(p)
+	info.nullable = true
+	return info
+}
+
+func (a *firstAnalyzer) analyze(p *Parser) firstInfo {
+	children := p.children
+	switch p.kind {
+	case "Ch":
+		return firstInfo{set: singleRune(p.args[0].(rune), false), known: true}
+	case "ChFold", "ChFoldRaw":
+		return firstInfo{set: singleRune(p.args[0].(rune), true), known: true}
+	case "Chs":
+		var set runeSet
+		for _, arg := range p.args {
+			set = set.union(singleRune(arg.(rune), false))
+		}
+		return firstInfo{set: set, known: true}
+	case "Not":
+		c := p.args[0].(rune)
+		return firstInfo{set: complement(runeSet{c, c}), known: true}
+	case "Range":
+		lo, hi := p.args[0].(rune), p.args[1].(rune)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		return firstInfo{set: runeSet{lo, hi}, known: true}
+	case "CharClass":
+		var set runeSet
+		for k := 1; k+1 < len(p.args); k += 2 {
+			set = set.union(runeSet{p.args[k].(rune), p.args[k+1].(rune)})
+		}
+		if p.args[0].(bool) {
+			set = complement(set)
+		}
+		return firstInfo{set: set, known: true}
+	case "Any":
+		return firstInfo{set: runeSet{0, unicode.MaxRune}, known: true}
+	case "Str", "Keyword":
+		set := firstString(p.args[0].(string), false)
+		return firstInfo{set: set, nullable: set == nil, known: true}
+	case "StrFold", "StrFoldRaw", "KeywordFold":
+		set := firstString(p.args[0].(string), true)
+		return firstInfo{set: set, nullable: set == nil, known: true}
+	case "Literals", "LiteralsMap":
+		info := firstInfo{known: true}
+		for _, arg := range p.args {
+			info.set = info.set.union(firstString(arg.(string), false))
+			info.nullable = info.nullable || arg.(string) == ""
+		}
+		return info
+	case "Succeed", "End":
+		return firstInfo{nullable: true, known: true}
+	case "Fail":
+		return firstInfo{known: true}
+	case "NewParser", "Compiled", "Map":
+		if len(children) == 0 {
+			return unknownFirst
+		}
+		return a.first(children[0])
+	case "And", "Seq", "SkipFirst", "SkipSecond":
+		return a.sequence(children...)
+	case "Surround":
+		return a.sequence(children[1], children[0])
+	case "Or", "OneOf":
+		info := firstInfo{known: true}
+		for _, child := range children {
+			f := a.first(child)
+			if !f.known {
+				return unknownFirst
+			}
+			info.set = info.set.union(f.set)
+			info.nullable = info.nullable || f.nullable
+		}
+		return info
+	case "Many", "Opt", "AtMost", "SepEndBy", "SepEndByKeep", "EndBy", "EndByKeep":
+		return a.optional(children[len(children)-1])
+	case "SepBy", "SepByKeep":
+		return a.optional(children[1])
+	case "Many1", "Separate", "SepBy1", "SepBy1Keep":
+		return a.first(children[len(children)-1])
+	case "Times", "AtLeast", "Repeat":
+		if p.args[0].(int) == 0 {
+			return a.optional(children[0])
+		}
+		return a.first(children[0])
+	case "Identifier", "IdentifierFold":
+		return a.first(children[0])
+	}
+	return unknownFirst
+}
+
+// complement 字符集合在全部Unicode字符中的补集
+func complement(s runeSet) runeSet {
+	result := make(runeSet, 0, len(s)+2)
+	next := rune(0)
+	for k := 0; k+1 < len(s); k += 2 {
+		if s[k] > next {
+			result = append(result, next, s[k]-1)
+		}
+		next = s[k+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		result = append(result, next, unicode.MaxRune)
+	}
+	return result
+}
+
+// dispatchTable 有序选择的跳转表。对于每个字符，只有FIRST集包含该字符、可能不消耗字符而成功或无法分析的分支
+// 才可能成功，其余分支无需尝试。候选分支总是包含最后一个分支，使全部失败时的错误与依次尝试所有分支时一致
+type dispatchTable struct {
+	infos []firstInfo
+	ascii [utf8.RuneSelf][]int
+}
+
+func newDispatchTable(parsers []*Parser) *dispatchTable {
+	a := &firstAnalyzer{cache: make(map[*Parser]firstInfo), visiting: make(map[*Parser]bool)}
+	t := &dispatchTable{}
+	for _, p := range parsers {
+		t.infos = append(t.infos, a.first(p))
+	}
+	for c := rune(0); c < utf8.RuneSelf; c++ {
+		t.ascii[c] = t.candidates(c)
+	}
+	return t
+}
+
+func (t *dispatchTable) candidates(c rune) []int {
+	var indices []int
+	last := len(t.infos) - 1
+	for k, info := range t.infos {
+		if k == last || !info.known || info.nullable || info.set.contains(c) {
+			indices = append(indices, k)
+		}
+	}
+	return indices
+}
+
+// lookup 获取以c开头的输入上需要依次尝试的分支
+func (t *dispatchTable) lookup(c rune) []int {
+	if c >= 0 && c < utf8.RuneSelf {
+		return t.ascii[c]
+	}
+	return t.candidates(c)
+}
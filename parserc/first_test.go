@@ -0,0 +1,81 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"unicode"
+)
+
+func firstOf(p *Parser) firstInfo {
+	a := &firstAnalyzer{cache: make(map[*Parser]firstInfo), visiting: make(map[*Parser]bool)}
+	return a.first(p)
+}
+
+func TestRuneSet(t *testing.T) {
+	s := runeSet{'a', 'c'}.union(runeSet{'x', 'x'}).union(runeSet{'d', 'f'})
+	assert.Equal(t, runeSet{'a', 'f', 'x', 'x'}, s)
+	assert.True(t, s.contains('a'))
+	assert.True(t, s.contains('e'))
+	assert.True(t, s.contains('x'))
+	assert.False(t, s.contains('g'))
+	assert.False(t, s.contains('y'))
+	assert.Equal(t, runeSet{0, 'a' - 1, 'g', 'w', 'y', unicode.MaxRune}, complement(s))
+}
+
+func TestFirst(t *testing.T) {
+	ws := Chs(' ', '\n').Many()
+	assert.Equal(t, firstInfo{runeSet{'a', 'a'}, false, true}, firstOf(Ch('a')))
+	assert.Equal(t, firstInfo{runeSet{'K', 'K', 'k', 'k', '\u212a', '\u212a'}, false, true}, firstOf(ChFold('k')))
+	assert.Equal(t, firstInfo{runeSet{'i', 'i'}, false, true}, firstOf(Keyword("if")))
+	assert.Equal(t, firstInfo{runeSet{'\n', '\n', ' ', ' '}, true, true}, firstOf(ws))
+	assert.Equal(t, firstInfo{runeSet{'\n', '\n', ' ', ' ', '[', '['}, false, true}, firstOf(Ch('[').Surround(ws)))
+	assert.Equal(t, firstInfo{runeSet{'0', '9'}, true, true}, firstOf(SepBy(Ch(','), Range('0', '9'))))
+	assert.Equal(t, firstInfo{runeSet{'0', '9', 'a', 'a'}, false, true}, firstOf(Opt(Range('0', '9'), nil).And(Ch('a'))))
+	assert.Equal(t, firstInfo{nil, true, true}, firstOf(Str("")))
+	assert.False(t, firstOf(Regex(`a`)).known)
+	assert.False(t, firstOf(Ch('a').Fatal()).known)
+	assert.False(t, firstOf(NewParser()).known)
+
+	expr := NewParser()
+	expr.Set(OneOf(Range('0', '9'), Skip(Ch('(')).And(expr).Skip(Ch(')'))))
+	assert.Equal(t, firstInfo{runeSet{'(', '(', '0', '9'}, false, true}, firstOf(expr))
+	left := NewParser()
+	left.Set(left.And(Ch('a')).Or(Ch('b')))
+	assert.False(t, firstOf(left).known)
+}
+
+func TestDispatchTable(t *testing.T) {
+	table := newDispatchTable([]*Parser{Str("true"), Str("false"), Range('0', '9').Many(), Ch('[')})
+	assert.Equal(t, []int{0, 2, 3}, table.lookup('t'))
+	assert.Equal(t, []int{1, 2, 3}, table.lookup('f'))
+	assert.Equal(t, []int{2, 3}, table.lookup('x'))
+	assert.Equal(t, []int{2, 3}, table.lookup('中'))
+
+	table = newDispatchTable([]*Parser{Ch('a'), Ch('b'), Ch('c')})
+	assert.Equal(t, []int{1, 2}, table.lookup('b'))
+	assert.Equal(t, []int{2}, table.lookup('c'))
+	assert.Equal(t, []int{2}, table.lookup('d'))
+}
+
+func TestOneOfDispatch(t *testing.T) {
+	calls := 0
+	count := Succeed(nil).Map(func(r any) any {
+		calls++
+		return r
+	})
+	p := OneOf(SkipFirst(count, Ch('a')), SkipFirst(count, Ch('b')), Ch('c'))
+	verifySuccess(t, p, "c", 'c')
+	assert.Equal(t, 0, calls)
+	verifySuccess(t, p, "b", 'b')
+	assert.Equal(t, 1, calls)
+
+	_, err := p.ParseToEnd("d")
+	assert.Equal(t, "parse error at row 1, col 1: expected c", err.Error())
+	_, err = p.ParseToEnd("")
+	assert.Equal(t, "parse error at row 1, col 1: unexpected end of input", err.Error())
+
+	overlap := OneOf(Str("ab"), Str("ac"), Ch('a').Many1())
+	verifySuccess(t, overlap, "ac", "ac")
+	verifySuccess(t, overlap, "aa", []any{'a', 'a'})
+	verifyFailed(t, overlap, "b")
+}
@@ -0,0 +1,113 @@
+package parserc
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenOptions 代码生成选项
+type GenOptions struct {
+	Package string // 生成代码所属的包名
+	Import  string // parserc包的导入路径，为空时使用parserc-go/parserc
+	Actions string // 包内map[string]func(any) any类型变量的名称，为空时不应用转换函数
+}
+
+// GenerateGo 根据语法规则文本生成使用parserc组合子的Go源码，每条规则生成一个首字母大写的包级变量
+func GenerateGo(src string, options GenOptions) ([]byte, error) {
+	rules, err := parseGrammar(src)
+	if err != nil {
+		return nil, err
+	}
+	importPath := options.Import
+	if importPath == "" {
+		importPath = "parserc-go/parserc"
+	}
+	names := make(map[string]string)
+	for _, rule := range rules {
+		name := goRuleName(rule.name)
+		for _, other := range names {
+			if other == name {
+				return nil, fmt.Errorf("grammar error at row %d, col %d: rule %s conflicts with another rule after renaming to %s", rule.row, rule.col, rule.name, name)
+			}
+		}
+		names[rule.name] = name
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by parserc-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", options.Package)
+	fmt.Fprintf(&buf, "import parserc %q\n\n", importPath)
+	buf.WriteString("var (\n")
+	for _, rule := range rules {
+		fmt.Fprintf(&buf, "\t%s = parserc.NewParser().Named(%q)\n", names[rule.name], rule.name)
+	}
+	buf.WriteString(")\n\n")
+	buf.WriteString("func init() {\n")
+	for _, rule := range rules {
+		expr := genExpr(rule.expr, names)
+		if options.Actions != "" {
+			expr = fmt.Sprintf("withAction(%q, %s)", rule.name, expr)
+		}
+		fmt.Fprintf(&buf, "\t%s.Set(%s)\n", names[rule.name], expr)
+	}
+	buf.WriteString("}\n")
+	if options.Actions != "" {
+		buf.WriteString("\nfunc withAction(name string, p *parserc.Parser) *parserc.Parser {\n")
+		fmt.Fprintf(&buf, "\tif action, exist := %s[name]; exist {\n", options.Actions)
+		buf.WriteString("\t\treturn p.Map(action)\n\t}\n\treturn p\n}\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+func goRuleName(name string) string {
+	rs := []rune(name)
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+func genExpr(n *grammarNode, names map[string]string) string {
+	children := make([]string, 0)
+	for _, c := range n.children {
+		children = append(children, genExpr(c, names))
+	}
+	switch n.kind {
+	case grammarSeq:
+		return fmt.Sprintf("parserc.Seq(%s)", strings.Join(children, ", "))
+	case grammarAlt:
+		return fmt.Sprintf("parserc.OneOf(%s)", strings.Join(children, ", "))
+	case grammarRepeat:
+		switch n.op {
+		case '*':
+			return children[0] + ".Many()"
+		case '+':
+			return children[0] + ".Many1()"
+		default:
+			return children[0] + ".Opt(nil)"
+		}
+	case grammarAnd:
+		return fmt.Sprintf("parserc.Peek(%s, parserc.Succeed(nil), parserc.Fail(\"predicate failed\"))", children[0])
+	case grammarNot:
+		return fmt.Sprintf("parserc.Peek(%s, parserc.Fail(\"unexpected input\"), parserc.Succeed(nil))", children[0])
+	case grammarLiteral:
+		return fmt.Sprintf("parserc.Str(%s)", strconv.Quote(n.text))
+	case grammarClass:
+		return genClass(n)
+	case grammarAny:
+		return "parserc.Any()"
+	default:
+		return names[n.text]
+	}
+}
+
+// genClass 生成与CompileGrammar相同的CharClass，使两种方式得到的解析器行为与错误信息一致
+func genClass(n *grammarNode) string {
+	args := []string{strconv.FormatBool(n.negate)}
+	for _, c := range n.ranges {
+		args = append(args, strconv.QuoteRune(c))
+	}
+	return fmt.Sprintf("parserc.CharClass(%s)", strings.Join(args, ", "))
+}
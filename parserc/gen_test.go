@@ -0,0 +1,46 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	code, err := GenerateGo(`
+	list  <- '[' items? ']'
+	items <- item (',' item)*
+	item  <- [0-9]+ / [^\]] / !'x' &. list
+	`, GenOptions{Package: "list"})
+	assert.Nil(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "list_gen.go", code, 0)
+	assert.Nil(t, err)
+	src := string(code)
+	assert.Contains(t, src, "// Code generated by parserc-gen. DO NOT EDIT.")
+	assert.Contains(t, src, "package list")
+	assert.Contains(t, src, `import parserc "parserc-go/parserc"`)
+	assert.Contains(t, src, `List.Set(parserc.Seq(parserc.Str("["), Items.Opt(nil), parserc.Str("]")))`)
+	assert.Contains(t, src, `parserc.CharClass(false, '0', '9').Many1()`)
+	assert.Contains(t, src, `List  = parserc.NewParser().Named("list")`)
+	assert.Contains(t, src, `parserc.CharClass(true, ']', ']')`)
+	assert.Contains(t, src, `parserc.Peek(parserc.Str("x"), parserc.Fail("unexpected input"), parserc.Succeed(nil))`)
+	assert.Contains(t, src, `parserc.Peek(parserc.Any(), parserc.Succeed(nil), parserc.Fail("predicate failed"))`)
+	assert.NotContains(t, src, "withAction")
+}
+
+func TestGenerateGoActions(t *testing.T) {
+	code, err := GenerateGo("a <- 'a'", GenOptions{Package: "p", Import: "example.com/parserc", Actions: "actions"})
+	assert.Nil(t, err)
+	src := string(code)
+	assert.Contains(t, src, `import parserc "example.com/parserc"`)
+	assert.Contains(t, src, `A.Set(withAction("a", parserc.Str("a")))`)
+	assert.Contains(t, src, "if action, exist := actions[name]; exist {")
+}
+
+func TestGenerateGoError(t *testing.T) {
+	_, err := GenerateGo("a <- b", GenOptions{Package: "p"})
+	assert.EqualError(t, err, "grammar error at row 1, col 6: undefined rule b")
+	_, err = GenerateGo("a <- 'a'\nA <- 'b'", GenOptions{Package: "p"})
+	assert.EqualError(t, err, "grammar error at row 2, col 1: rule A conflicts with another rule after renaming to A")
+}
@@ -0,0 +1,340 @@
+package parserc
+
+import "fmt"
+
+// 语法规则文本格式（同时支持PEG与EBNF的常用写法）：
+//
+//	rule <- expr          定义规则，也可以写作rule = expr;或rule ::= expr
+//	a b  /  a, b          顺序
+//	a / b  /  a | b       有序选择
+//	e* e+ e?              零次或多次、一次或多次、可选
+//	{ e }                 零次或多次
+//	&e !e                 肯定预测、否定预测，不消耗输入
+//	'abc' "abc"           字面量
+//	[a-z_] [^"]           字符集、取反字符集
+//	.                     任意字符
+//	( e )                 分组
+//	# ...  // ...         注释
+//
+// 规则之间没有自动跳过空白字符的语义，与PEG保持一致。
+
+// Grammar 由语法规则文本编译得到的解析器集合
+type Grammar struct {
+	rules map[string]*Parser
+	names []string
+}
+
+// Start 获取第一条规则对应的解析器
+func (g *Grammar) Start() *Parser {
+	return g.rules[g.names[0]]
+}
+
+// Rule 获取指定名称的规则对应的解析器，规则不存在时返回nil
+func (g *Grammar) Rule(name string) *Parser {
+	return g.rules[name]
+}
+
+// Names 获取所有规则名称，按定义顺序排列
+func (g *Grammar) Names() []string {
+	return append([]string{}, g.names...)
+}
+
+type grammarNodeKind int
+
+const (
+	grammarSeq grammarNodeKind = iota
+	grammarAlt
+	grammarRepeat
+	grammarAnd
+	grammarNot
+	grammarLiteral
+	grammarClass
+	grammarAny
+	grammarRef
+)
+
+type grammarNode struct {
+	kind     grammarNodeKind
+	text     string
+	op       rune
+	negate   bool
+	ranges   []rune
+	children []*grammarNode
+	row      int
+	col      int
+}
+
+type grammarRule struct {
+	name string
+	expr *grammarNode
+	row  int
+	col  int
+}
+
+// position 解析成功时返回结果及其起始位置
+type position struct {
+	result any
+	row    int
+	col    int
+}
+
+func withPosition(p *Parser) *Parser {
+	return &Parser{kind: "withPosition", children: []*Parser{p}, parse: func(input Input) (ParseResult, error) {
+		r, err := p.run(input)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		return ParseResult{position{r.Result, input.Row(), input.Col()}, r.Remain}, nil
+	}}
+}
+
+var (
+	grammarComment = Or(Ch('#'), Str("//")).And(Not('\n').Many())
+	grammarWs      = Or(Chs(' ', '\t', '\n', '\r'), grammarComment).Many()
+	grammarIdent   = Identifier(OneOf(Range('a', 'z'), Range('A', 'Z'), Ch('_')), OneOf(Range('a', 'z'), Range('A', 'Z'), Range('0', '9'), Ch('_'))).Skip(grammarWs)
+	grammarEscape  = Skip(Ch('\\')).And(Any()).Map(func(c any) any {
+		switch c.(rune) {
+		case 'n':
+			return '\n'
+		case 'r':
+			return '\r'
+		case 't':
+			return '\t'
+		}
+		return c
+	})
+	grammarDefine = OneOf(Str("<-"), Str("::="), Str("=")).Named("<-").Skip(grammarWs)
+	grammarExpr   = NewParser()
+	grammarLit    = OneOf(grammarQuoted('\''), grammarQuoted('"')).Skip(grammarWs).Map(func(s any) any {
+		return &grammarNode{kind: grammarLiteral, text: s.(string)}
+	})
+	grammarClassChar = Or(grammarEscape, Not(']'))
+	grammarCharClass = Skip(Ch('[')).And(Ch('^').Opt(nil).And(grammarClassChar.And(Skip(Ch('-')).And(grammarClassChar).Opt(nil)).Many())).Skip(grammarClose(']')).Skip(grammarWs).Map(buildGrammarClass)
+	grammarRuleRef   = grammarIdent.Skip(Peek(grammarDefine, Fail("unexpected definition"), Succeed(nil))).Map(func(s any) any {
+		return &grammarNode{kind: grammarRef, text: s.(string)}
+	})
+	grammarPrimary = withPosition(OneOf(
+		grammarLit,
+		grammarCharClass,
+		Ch('.').Skip(grammarWs).Map(func(any) any {
+			return &grammarNode{kind: grammarAny}
+		}),
+		grammarRuleRef,
+		Skip(Ch('(').Skip(grammarWs)).And(grammarExpr).Skip(grammarClose(')').Skip(grammarWs)),
+		Skip(Ch('{').Skip(grammarWs)).And(grammarExpr).Skip(grammarClose('}').Skip(grammarWs)).Map(func(n any) any {
+			return &grammarNode{kind: grammarRepeat, op: '*', children: []*grammarNode{n.(*grammarNode)}}
+		}),
+	)).Named("expression").Map(func(p any) any {
+		n := p.(position).result.(*grammarNode)
+		n.row, n.col = p.(position).row, p.(position).col
+		return n
+	})
+	grammarSuffix = grammarPrimary.And(Chs('*', '+', '?').Skip(grammarWs).Many()).Map(func(p any) any {
+		n := p.(Pair).First.(*grammarNode)
+		for _, op := range p.(Pair).Second.([]any) {
+			n = &grammarNode{kind: grammarRepeat, op: op.(rune), children: []*grammarNode{n}}
+		}
+		return n
+	})
+	grammarPrefix = Chs('&', '!').Skip(grammarWs).Opt(nil).And(grammarSuffix).Map(func(p any) any {
+		n := p.(Pair).Second.(*grammarNode)
+		switch p.(Pair).First {
+		case '&':
+			return &grammarNode{kind: grammarAnd, children: []*grammarNode{n}}
+		case '!':
+			return &grammarNode{kind: grammarNot, children: []*grammarNode{n}}
+		}
+		return n
+	})
+	grammarSequence = SepBy1(Ch(',').Skip(grammarWs).Opt(nil), grammarPrefix).Map(func(ns any) any {
+		if len(ns.([]any)) == 1 {
+			return ns.([]any)[0]
+		}
+		return &grammarNode{kind: grammarSeq, children: toGrammarNodes(ns)}
+	})
+	grammarRuleDef = withPosition(grammarIdent.Skip(grammarDefine).And(grammarExpr).Skip(Ch(';').Skip(grammarWs).Opt(nil))).Map(func(p any) any {
+		pair := p.(position).result.(Pair)
+		return grammarRule{pair.First.(string), pair.Second.(*grammarNode), p.(position).row, p.(position).col}
+	})
+	grammarRules = Skip(grammarWs).And(grammarRuleDef.Many1())
+)
+
+func init() {
+	grammarExpr.Set(SepBy1(Chs('/', '|').Skip(grammarWs), grammarSequence).Map(func(ns any) any {
+		if len(ns.([]any)) == 1 {
+			return ns.([]any)[0]
+		}
+		return &grammarNode{kind: grammarAlt, children: toGrammarNodes(ns)}
+	}))
+}
+
+func grammarQuoted(q rune) *Parser {
+	return Skip(Ch(q)).And(Or(grammarEscape, Not(q)).Many()).Skip(grammarClose(q)).Map(joinResults)
+}
+
+// grammarClose 匹配右括号、右引号等结束符号，未能匹配时报告期望该符号
+func grammarClose(c rune) *Parser {
+	return Ch(c).Named(string(c))
+}
+
+// furthestFailure 记录命名的语法元素中位置最远的失败。语法规则文本有误时，
+// 出错规则之后的解析均会回溯，最远的失败才是真正出错的位置
+type furthestFailure struct {
+	err *parseFailure
+}
+
+func (f *furthestFailure) enter(*Parser, Input) {}
+
+func (f *furthestFailure) exit(_ *Parser, _ Input, _ ParseResult, err error) {
+	// 位置相同时保留最后一次失败，即回溯到最外层之前最后尝试的语法元素
+	if e, ok := err.(*parseFailure); ok && (f.err == nil || e.offset >= f.err.offset) {
+		f.err = e
+	}
+}
+
+func buildGrammarClass(p any) any {
+	n := &grammarNode{kind: grammarClass, negate: p.(Pair).First != nil}
+	for _, e := range p.(Pair).Second.([]any) {
+		lo := e.(Pair).First.(rune)
+		hi := lo
+		if e.(Pair).Second != nil {
+			hi = e.(Pair).Second.(rune)
+		}
+		n.ranges = append(n.ranges, lo, hi)
+	}
+	return n
+}
+
+func toGrammarNodes(ns any) []*grammarNode {
+	nodes := make([]*grammarNode, 0)
+	for _, n := range ns.([]any) {
+		nodes = append(nodes, n.(*grammarNode))
+	}
+	return nodes
+}
+
+// parseGrammar 解析语法规则文本，并检查规则是否重复定义、引用的规则是否存在
+func parseGrammar(src string) ([]grammarRule, error) {
+	furthest := &furthestFailure{}
+	r, err := withHook(grammarRules, "Furthest", func() ruleHook {
+		return furthest
+	}, func(ruleHook) {}).ParseToEnd(src)
+	if err != nil {
+		if e, ok := err.(*parseFailure); ok && furthest.err != nil && furthest.err.offset >= e.offset {
+			err = furthest.err
+		}
+		return nil, fmt.Errorf("grammar syntax error: %w", err)
+	}
+	rules := make([]grammarRule, 0)
+	defined := make(map[string]bool)
+	for _, e := range r.([]any) {
+		rule := e.(grammarRule)
+		if defined[rule.name] {
+			return nil, fmt.Errorf("grammar error at row %d, col %d: duplicate rule %s", rule.row, rule.col, rule.name)
+		}
+		defined[rule.name] = true
+		rules = append(rules, rule)
+	}
+	var check func(n *grammarNode) error
+	check = func(n *grammarNode) error {
+		if n.kind == grammarRef && !defined[n.text] {
+			return fmt.Errorf("grammar error at row %d, col %d: undefined rule %s", n.row, n.col, n.text)
+		}
+		for _, c := range n.children {
+			if err := check(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, rule := range rules {
+		if err := check(rule.expr); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// CompileGrammar 编译语法规则文本，actions用于按规则名称转换对应规则的解析结果
+func CompileGrammar(src string, actions map[string]func(any) any) (*Grammar, error) {
+	rules, err := parseGrammar(src)
+	if err != nil {
+		return nil, err
+	}
+	g := &Grammar{make(map[string]*Parser), nil}
+	for _, rule := range rules {
+		g.rules[rule.name] = NewParser().Named(rule.name)
+		g.names = append(g.names, rule.name)
+	}
+	for name := range actions {
+		if _, exist := g.rules[name]; !exist {
+			return nil, fmt.Errorf("grammar error: action for undefined rule %s", name)
+		}
+	}
+	for _, rule := range rules {
+		p := g.compile(rule.expr)
+		if action, exist := actions[rule.name]; exist {
+			p = p.Map(action)
+		}
+		g.rules[rule.name].Set(p)
+	}
+	return g, nil
+}
+
+func (g *Grammar) compile(n *grammarNode) *Parser {
+	children := make([]*Parser, 0)
+	for _, c := range n.children {
+		children = append(children, g.compile(c))
+	}
+	switch n.kind {
+	case grammarSeq:
+		return Seq(children...)
+	case grammarAlt:
+		return OneOf(children[0], children[1], children[2:]...)
+	case grammarRepeat:
+		switch n.op {
+		case '*':
+			return children[0].Many()
+		case '+':
+			return children[0].Many1()
+		default:
+			return children[0].Opt(nil)
+		}
+	case grammarAnd:
+		return Peek(children[0], Succeed(nil), Fail("predicate failed"))
+	case grammarNot:
+		return Peek(children[0], Fail("unexpected input"), Succeed(nil))
+	case grammarLiteral:
+		return Str(n.text)
+	case grammarClass:
+		return CharClass(n.negate, n.ranges...)
+	case grammarAny:
+		return Any()
+	default:
+		return g.rules[n.text]
+	}
+}
+
+// CharClass 匹配字符类，ranges为两两一组的闭区间，negate为true时匹配不在任何区间内的字符
+func CharClass(negate bool, ranges ...rune) *Parser {
+	return &Parser{kind: "CharClass", args: append([]any{negate}, runeArgs(ranges)...), parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
+			return emptyParseResult, parseError(input, "unexpected end of input")
+		}
+		c := input.Current()
+		in := false
+		for k := 0; k < len(ranges); k += 2 {
+			if ranges[k] <= c && c <= ranges[k+1] {
+				in = true
+				break
+			}
+		}
+		if in == negate {
+			return emptyParseResult, parseError(input, fmt.Sprintf("unexpected %c", c))
+		}
+		return ParseResult{c, input.Next()}, nil
+	}}
+}
@@ -0,0 +1,103 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestCompileGrammar(t *testing.T) {
+	g, err := CompileGrammar(`
+	# PEG
+	list  <- '[' items? ']'
+	items <- item (',' item)*
+	item  <- [0-9]+ / list
+	`, nil)
+	assert.Nil(t, err)
+	verifySuccess(t, g.Start(), "[]", []any{"[", nil, "]"})
+	verifySuccess(t, g.Rule("item"), "12", []any{'1', '2'})
+	verifySuccess(t, g.Start(), "[1,[2]]", []any{"[", []any{[]any{'1'}, []any{[]any{",", []any{"[", []any{[]any{'2'}, []any{}}, "]"}}}}, "]"})
+	verifyFailed(t, g.Start(), "[1,]")
+	verifyFailed(t, g.Start(), "[a]")
+	assert.Nil(t, g.Rule("missing"))
+	assert.Equal(t, []string{"list", "items", "item"}, g.Names())
+}
+
+func TestCompileGrammarActions(t *testing.T) {
+	g, err := CompileGrammar(`
+	expr   = term, { ('+' | '-'), term } ;
+	term   = number | '(', expr, ')' ;
+	number ::= [0-9]+ ;
+	`, map[string]func(any) any{
+		"number": func(r any) any {
+			v, _ := strconv.Atoi(joinResults(r).(string))
+			return v
+		},
+		"term": func(r any) any {
+			if rs, ok := r.([]any); ok {
+				return rs[1]
+			}
+			return r
+		},
+		"expr": func(r any) any {
+			v := r.([]any)[0].(int)
+			for _, e := range r.([]any)[1].([]any) {
+				if e.([]any)[0] == "+" {
+					v += e.([]any)[1].(int)
+				} else {
+					v -= e.([]any)[1].(int)
+				}
+			}
+			return v
+		},
+	})
+	assert.Nil(t, err)
+	verifySuccess(t, g.Start(), "1+2-3", 0)
+	verifySuccess(t, g.Start(), "10-(2+3)", 5)
+	verifyFailed(t, g.Start(), "1+")
+}
+
+func TestCompileGrammarPredicates(t *testing.T) {
+	g, err := CompileGrammar(`
+	ident   <- !keyword [a-z_] [a-z0-9_]*
+	keyword <- ("if" / "else") ![a-z0-9_]
+	str     <- "\"" (!"\"" .)* "\""
+	notq    <- [^"\n]
+	peek    <- &"a" .
+	`, nil)
+	assert.Nil(t, err)
+	verifySuccess(t, g.Rule("ident"), "iffy", []any{nil, 'i', []any{'f', 'f', 'y'}})
+	verifyFailed(t, g.Rule("ident"), "if")
+	verifySuccess(t, g.Rule("str"), `"a"`, []any{`"`, []any{[]any{nil, 'a'}}, `"`})
+	verifySuccess(t, g.Rule("notq"), "x", 'x')
+	verifyFailed(t, g.Rule("notq"), `"`)
+	verifyFailed(t, g.Rule("notq"), "\n")
+	verifySuccess(t, g.Rule("peek"), "a", []any{nil, 'a'})
+	verifyFailed(t, g.Rule("peek"), "b")
+}
+
+func TestCompileGrammarError(t *testing.T) {
+	_, err := CompileGrammar("a <- b", nil)
+	assert.EqualError(t, err, "grammar error at row 1, col 6: undefined rule b")
+	_, err = CompileGrammar("a <- 'x'\na <- 'y'", nil)
+	assert.EqualError(t, err, "grammar error at row 2, col 1: duplicate rule a")
+	_, err = CompileGrammar("a <- 'x' )", nil)
+	assert.EqualError(t, err, "grammar syntax error: parse error at row 1, col 10: expected expression")
+	_, err = CompileGrammar("a <- 'x'", map[string]func(any) any{"b": nil})
+	assert.EqualError(t, err, "grammar error: action for undefined rule b")
+}
+
+func TestCompileGrammarSyntaxError(t *testing.T) {
+	for src, msg := range map[string]string{
+		"a <- 'x'\nb <- ( 'y' \nc <- 'z'": "row 3, col 1: expected )",
+		"a <- 'x' (":                      "row 1, col 11: expected expression",
+		"a <- [abc":                       "row 1, col 10: expected ]",
+		"a <- 'abc":                       "row 1, col 10: expected '",
+		"a <- \"x\" / { 'y' ":             "row 1, col 18: expected }",
+		"a 'x'":                           "row 1, col 3: expected <-",
+		"a <- 'x' /":                      "row 1, col 11: expected expression",
+	} {
+		_, err := CompileGrammar(src, nil)
+		assert.EqualError(t, err, "grammar syntax error: parse error at "+msg, src)
+	}
+}
@@ -0,0 +1,97 @@
+package parserc
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// IncompleteError 输入不完整，需要更多数据才能确定解析结果
+type IncompleteError struct {
+	Row    int  // 输入末尾的行号
+	Col    int  // 输入末尾的列号
+	Offset int  // 字节输入末尾的字节偏移，增量解析时跨越多次解析累计
+	Binary bool // 是否为字节输入，字节输入与其他解析错误一样以字节偏移表示位置
+}
+
+func (e *IncompleteError) Error() string {
+	if e.Binary {
+		return fmt.Sprintf("parse error at offset %d: incomplete input", e.Offset)
+	}
+	return fmt.Sprintf("parse error at row %d, col %d: incomplete input", e.Row, e.Col)
+}
+
+// IncrementalParser 增量解析器，用于分块到达的输入。通过Feed追加数据，通过Next依次解析出结果，
+// 数据不足以确定解析结果时Next返回IncompleteError，追加数据后可再次调用Next继续解析
+type IncrementalParser struct {
+	p      *Parser
+	buf    []byte
+	closed bool
+	row    int
+	col    int
+	binary bool
+}
+
+// Incremental 创建以当前解析器依次解析输入的增量解析器
+func (p *Parser) Incremental() *IncrementalParser {
+	return &IncrementalParser{p: p, row: 1, col: 1}
+}
+
+// IncrementalBytes 与Incremental相同，但将数据作为字节输入解析
+func (p *Parser) IncrementalBytes() *IncrementalParser {
+	return &IncrementalParser{p: p, row: 1, col: 1, binary: true}
+}
+
+// Feed 追加输入数据
+func (ip *IncrementalParser) Feed(data []byte) {
+	ip.buf = append(ip.buf, data...)
+}
+
+// Close 标记输入结束，此后到达末尾的解析不再返回IncompleteError
+func (ip *IncrementalParser) Close() {
+	ip.closed = true
+}
+
+// Buffered 获取尚未被解析的数据
+func (ip *IncrementalParser) Buffered() []byte {
+	return ip.buf
+}
+
+// Next 从尚未解析的数据开头解析下一个结果，成功时丢弃已解析的数据。
+// 数据不足时返回IncompleteError，已关闭且没有剩余数据时返回io.EOF
+func (ip *IncrementalParser) Next() (any, error) {
+	if ip.closed && len(ip.buf) == 0 {
+		return nil, io.EOF
+	}
+	n := len(ip.buf)
+	if !ip.closed && !ip.binary {
+		n = completeRunes(ip.buf)
+	}
+	s := string(ip.buf[:n])
+	input := Input{s, 0, ip.row, ip.col, &parseContext{partial: !ip.closed}, nil, 0, ip.binary, nil, nil}
+	r, err := ip.parse(input)
+	if err != nil {
+		return nil, err
+	}
+	ip.buf = ip.buf[r.Remain.bytePos():]
+	ip.row, ip.col = r.Remain.row, r.Remain.col
+	return r.Result, nil
+}
+
+func (ip *IncrementalParser) parse(input Input) (r ParseResult, err error) {
+	defer catchAbort(&err)
+	return ip.p.run(input)
+}
+
+// completeRunes 获取buf中不包含末尾残缺UTF-8编码的前缀长度
+func completeRunes(buf []byte) int {
+	for k := len(buf) - 1; k >= 0 && k >= len(buf)-utf8.UTFMax; k-- {
+		if utf8.RuneStart(buf[k]) {
+			if !utf8.FullRune(buf[k:]) {
+				return k
+			}
+			break
+		}
+	}
+	return len(buf)
+}
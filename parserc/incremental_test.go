@@ -0,0 +1,172 @@
+package parserc
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func verifyIncomplete(t *testing.T, ip *IncrementalParser) {
+	_, err := ip.Next()
+	var incomplete *IncompleteError
+	assert.True(t, errors.As(err, &incomplete), "%v", err)
+}
+
+func TestIncremental(t *testing.T) {
+	message := Str("GET ").And(Range('a', 'z').Many1()).Skip(Ch('\n'))
+	ip := message.Incremental()
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("GE"))
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("T ab"))
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("c\nGET x"))
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, Pair{"GET ", []any{'a', 'b', 'c'}}, r)
+	assert.Equal(t, []byte("GET x"), ip.Buffered())
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("\nPUT"))
+	r, err = ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, Pair{"GET ", []any{'x'}}, r)
+	_, err = ip.Next()
+	assert.Equal(t, "parse error at row 3, col 1: expected GET ", err.Error())
+}
+
+func TestIncrementalMismatch(t *testing.T) {
+	ip := Str("abc").Incremental()
+	ip.Feed([]byte("ax"))
+	_, err := ip.Next()
+	var incomplete *IncompleteError
+	assert.False(t, errors.As(err, &incomplete))
+	assert.Equal(t, "parse error at row 1, col 1: expected abc", err.Error())
+}
+
+func TestIncrementalBacktrack(t *testing.T) {
+	ip := Or(Str("abc"), Str("ab")).Incremental()
+	ip.Feed([]byte("ab"))
+	verifyIncomplete(t, ip)
+	ip.Close()
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", r)
+	_, err = ip.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestIncrementalRegex(t *testing.T) {
+	ip := Regex("abc").Incremental()
+	ip.Feed([]byte("ab"))
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("cabx"))
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", r)
+	_, err = ip.Next()
+	assert.Equal(t, "parse error at row 1, col 4: expected /abc/", err.Error())
+	for _, s := range []string{"ax", "abx", "x"} {
+		ip = Regex("abc").Incremental()
+		ip.Feed([]byte(s))
+		_, err = ip.Next()
+		assert.Equal(t, "parse error at row 1, col 1: expected /abc/", err.Error(), s)
+	}
+
+	ip = OneOf(Regex("[0-9]+;"), Ch('x')).Incremental()
+	ip.Feed([]byte("12"))
+	verifyIncomplete(t, ip)
+	ip.Feed([]byte("3;x"))
+	r, err = ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "123;", r)
+	r, err = ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, 'x', r)
+
+	ip = Regex("abc").Incremental()
+	ip.Feed([]byte("ab"))
+	ip.Close()
+	_, err = ip.Next()
+	assert.Equal(t, "parse error at row 1, col 1: expected /abc/", err.Error())
+}
+
+func TestRegexPrefix(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		s       string
+		prefix  bool
+	}{
+		{"abc", "", true},
+		{"abc", "ab", true},
+		{"abc", "ax", false},
+		{"abc", "abx", false},
+		{"[0-9]+;", "123", true},
+		{"[0-9]+;", "12a", false},
+		{"(?i)select", "SEL", true},
+		{"a|bcd", "bc", true},
+		{"a|bcd", "c", false},
+		{`a\b`, "a", true},
+		{`\bx`, "", true},
+		{"a$", "a", true},
+		{"a.b", "a\n", false},
+		{"(?s)a.b", "a\n", true},
+		{"中文", "中", true},
+		{"中文", "中a", false},
+	} {
+		re := regexp.MustCompile(`^(?:` + c.pattern + `)`)
+		assert.Equal(t, c.prefix, isRegexPrefix(regexProg(re.String()), c.s), "%s %q", c.pattern, c.s)
+	}
+}
+
+func TestIncrementalClose(t *testing.T) {
+	ip := Ch('a').Many().Incremental()
+	ip.Feed([]byte("aa"))
+	verifyIncomplete(t, ip)
+	ip.Close()
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []any{'a', 'a'}, r)
+	_, err = ip.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestIncrementalUTF8(t *testing.T) {
+	ip := Str("你好").Incremental()
+	data := []byte("你好")
+	ip.Feed(data[:4])
+	verifyIncomplete(t, ip)
+	ip.Feed(data[4:])
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "你好", r)
+	assert.Equal(t, 0, len(ip.Buffered()))
+}
+
+func TestIncrementalEnd(t *testing.T) {
+	ip := Ch('a').Skip(End()).Incremental()
+	ip.Feed([]byte("a"))
+	verifyIncomplete(t, ip)
+	ip.Close()
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, 'a', r)
+	verifyIncomplete(t, Any().Incremental())
+}
+
+func TestEnd(t *testing.T) {
+	verifySuccess(t, End(), "", nil)
+	verifyFailed(t, End(), "a")
+	verifySuccess(t, Ch('a').Skip(End()), "a", 'a')
+	assert.Equal(t, "End()", End().String())
+}
+
+func TestIncrementalMultiByte(t *testing.T) {
+	ip := Str("中文").Skip(Ch(';')).Incremental()
+	ip.Feed([]byte("中文;中"))
+	r, err := ip.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "中文", r)
+	assert.Equal(t, []byte("中"), ip.Buffered())
+}
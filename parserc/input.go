@@ -1,39 +1,90 @@
 package parserc
 
-import "unicode/utf8"
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
 
 // Input 输入流
 type Input struct {
-	str   string
-	index int
-	row   int
-	col   int
+	str    string
+	index  int
+	row    int
+	col    int
+	ctx    *parseContext
+	chunk  *chunk  // 从io.Reader读取的输入所在的数据块，为nil时输入来自str
+	offset int     // 当前字符在chunk中的位置，输入来自str时为当前字符在str中的字节偏移
+	binary bool    // 是否为字节输入，字节输入中index为字节偏移，每个字节作为一个字符
+	tokens []Token // 词法单元输入，不为nil时index为词法单元的序号
+	src    *source // 输入的来源，为nil时输入没有名称
 }
 
-// CreateInput 创建输入流
+// CreateInput 创建输入流。不检查s的编码，无效的UTF-8编码按U+FFFD解析，需要检查编码时使用DecodeInput
 func CreateInput(s string) Input {
-	return Input{s, 0, 1, 1}
+	return Input{s, 0, 1, 1, nil, nil, 0, false, nil, nil}
+}
+
+// CreateBytesInput 创建字节输入流，每个字节作为一个字符，用于解析二进制格式
+func CreateBytesInput(b []byte) Input {
+	return Input{string(b), 0, 1, 1, nil, nil, 0, true, nil, nil}
 }
 
 // End 判断是否到达输入流末尾
 func (p Input) End() bool {
-	return p.index == utf8.RuneCountInString(p.str)
+	if p.tokens != nil {
+		return p.index == len(p.tokens)
+	}
+	if p.chunk != nil {
+		return p.offset == len(p.chunk.runes)
+	}
+	if p.binary {
+		return p.index == len(p.str)
+	}
+	return p.offset == len(p.str)
 }
 
 // Next 输入流向后移一位
 func (p Input) Next() Input {
+	if p.tokens != nil {
+		row, col := p.row, p.col
+		if p.index+1 < len(p.tokens) {
+			row, col = p.tokens[p.index+1].Pos()
+		}
+		return Input{p.str, p.index + 1, row, col, p.ctx, nil, 0, false, p.tokens, p.src}
+	}
+	if p.binary {
+		return Input{p.str, p.index + 1, p.row, p.col + 1, p.ctx, nil, 0, true, nil, p.src}
+	}
 	row := p.row
 	col := p.col + 1
 	if p.Current() == '\n' {
 		row++
 		col = 1
 	}
-	return Input{p.str, p.index + 1, row, col}
+	if p.chunk != nil {
+		c, offset := p.chunk, p.offset+1
+		if offset == len(c.runes) {
+			if next := c.load(); next != nil {
+				c, offset = next, 0
+			}
+		}
+		return Input{p.str, p.index + 1, row, col, p.ctx, c, offset, false, nil, p.src}
+	}
+	_, size := utf8.DecodeRuneInString(p.str[p.offset:])
+	return Input{p.str, p.index + 1, row, col, p.ctx, nil, p.offset + size, false, nil, p.src}
 }
 
-// Current 获取当前字符
+// Current 获取当前字符，词法单元输入不支持获取字符
 func (p Input) Current() rune {
-	return []rune(p.str)[p.index]
+	if p.chunk != nil {
+		return p.chunk.runes[p.offset]
+	}
+	if p.binary {
+		return rune(p.str[p.index])
+	}
+	c, _ := utf8.DecodeRuneInString(p.str[p.offset:])
+	return c
 }
 
 // Row 获取当前行号
@@ -45,3 +96,56 @@ func (p Input) Row() int {
 func (p Input) Col() int {
 	return p.col
 }
+
+// TextTo 获取从当前位置到end之间的文本，end应为当前输入流之后的位置
+func (p Input) TextTo(end Input) string {
+	if p.tokens != nil {
+		texts := make([]string, 0, end.index-p.index)
+		for _, t := range p.tokens[p.index:end.index] {
+			texts = append(texts, fmt.Sprint(t))
+		}
+		return strings.Join(texts, " ")
+	}
+	if p.chunk != nil {
+		rs := make([]rune, 0, end.index-p.index)
+		for c, offset := p.chunk, p.offset; len(rs) < end.index-p.index; c, offset = c.next, 0 {
+			n := len(c.runes) - offset
+			if n > end.index-p.index-len(rs) {
+				n = end.index - p.index - len(rs)
+			}
+			rs = append(rs, c.runes[offset:offset+n]...)
+		}
+		return string(rs)
+	}
+	if p.binary {
+		return p.str[p.index:end.index]
+	}
+	return p.str[p.offset:end.offset]
+}
+
+// eof 供解析器判断是否到达输入流末尾，不完整的输入到达末尾时无法判断，此时中止解析并返回IncompleteError
+func (p Input) eof() bool {
+	if !p.End() {
+		return false
+	}
+	if p.ctx != nil && p.ctx.partial {
+		p.incomplete()
+	}
+	return true
+}
+
+// incomplete 以输入不完整中止解析，p应为不完整的输入的末尾
+func (p Input) incomplete() {
+	if p.binary {
+		panic(abortPanic{&IncompleteError{p.row, p.col, p.col - 1, true}})
+	}
+	panic(abortPanic{&IncompleteError{Row: p.row, Col: p.col}})
+}
+
+// bytePos 获取字符串输入与字节输入中当前位置的字节偏移
+func (p Input) bytePos() int {
+	if p.binary {
+		return p.index
+	}
+	return p.offset
+}
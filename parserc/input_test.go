@@ -41,3 +41,23 @@ ghi`
 	input = input.Next().Next()
 	assert.True(t, input.End())
 }
+
+func TestInputByteOffset(t *testing.T) {
+	input := CreateInput("a中\n文b")
+	assert.Equal(t, 0, input.offset)
+	input = input.Next()
+	assert.Equal(t, '中', input.Current())
+	assert.Equal(t, 1, input.offset)
+	input = input.Next()
+	assert.Equal(t, 4, input.offset)
+	assert.Equal(t, 2, input.index)
+	input = input.Next()
+	assert.Equal(t, '文', input.Current())
+	assert.Equal(t, 2, input.row)
+	assert.Equal(t, 1, input.col)
+	end := input.Next().Next()
+	assert.True(t, end.End())
+	assert.Equal(t, 5, end.index)
+	assert.Equal(t, "文b", input.TextTo(end))
+	assert.Equal(t, 9, end.bytePos())
+}
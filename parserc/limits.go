@@ -0,0 +1,162 @@
+package parserc
+
+import (
+	"context"
+	"fmt"
+)
+
+// cancelCheckInterval 每执行多少步检查一次context是否已取消
+const cancelCheckInterval = 1024
+
+// CanceledError 解析因context取消或超时而中止
+type CanceledError struct {
+	Row int   // 中止时的行号
+	Col int   // 中止时的列号
+	Err error // context返回的错误
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("parse error at row %d, col %d: %s", e.Row, e.Col, e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// DepthLimitError 递归深度超过限制
+type DepthLimitError struct {
+	Row   int // 超出限制时的行号
+	Col   int // 超出限制时的列号
+	Limit int // 最大递归深度
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("parse error at row %d, col %d: maximum recursion depth %d exceeded", e.Row, e.Col, e.Limit)
+}
+
+// StepLimitError 解析步数超过限制
+type StepLimitError struct {
+	Row   int // 超出限制时的行号
+	Col   int // 超出限制时的列号
+	Limit int // 最大解析步数
+}
+
+func (e *StepLimitError) Error() string {
+	return fmt.Sprintf("parse error at row %d, col %d: maximum steps %d exceeded", e.Row, e.Col, e.Limit)
+}
+
+// InputSizeError 输入长度超过限制
+type InputSizeError struct {
+	Size  int // 输入长度（字节）
+	Limit int // 最大输入长度（字节）
+}
+
+func (e *InputSizeError) Error() string {
+	return fmt.Sprintf("input size %d exceeds maximum %d", e.Size, e.Limit)
+}
+
+// ParseOption 解析选项
+type ParseOption func(*parseLimits)
+
+// WithMaxDepth 限制递归深度，即同时处于执行中的命名规则与NewParser创建的解析器的层数
+func WithMaxDepth(n int) ParseOption {
+	return func(l *parseLimits) {
+		l.maxDepth = n
+	}
+}
+
+// WithMaxSteps 限制解析步数，即解析器被执行的总次数，用于防止指数级回溯
+func WithMaxSteps(n int) ParseOption {
+	return func(l *parseLimits) {
+		l.maxSteps = n
+	}
+}
+
+// WithMaxInputSize 限制输入长度（字节）
+func WithMaxInputSize(n int) ParseOption {
+	return func(l *parseLimits) {
+		l.maxInputSize = n
+	}
+}
+
+// parseLimits 一次解析过程的资源限制与计数，限制为0表示不限制
+type parseLimits struct {
+	ctx          context.Context
+	maxDepth     int
+	maxSteps     int
+	maxInputSize int
+	depth        int
+	steps        int
+}
+
+// abortPanic 超出限制、输入不完整或被包含的文件解析失败时通过panic中止整个解析过程，由catchAbort恢复
+type abortPanic struct {
+	err error
+}
+
+// catchAbort 恢复abortPanic并将其中的错误写入err，其他panic继续抛出
+func catchAbort(err *error) {
+	if r := recover(); r != nil {
+		ap, ok := r.(abortPanic)
+		if !ok {
+			panic(r)
+		}
+		*err = ap.err
+	}
+}
+
+func (l *parseLimits) run(p *Parser, input Input) (ParseResult, error) {
+	l.steps++
+	if l.maxSteps > 0 && l.steps > l.maxSteps {
+		panic(abortPanic{&StepLimitError{input.Row(), input.Col(), l.maxSteps}})
+	}
+	if l.steps%cancelCheckInterval == 0 {
+		if err := l.ctx.Err(); err != nil {
+			panic(abortPanic{&CanceledError{input.Row(), input.Col(), err}})
+		}
+	}
+	if p.name == "" && p.kind != "NewParser" {
+		return p.runHooks(input)
+	}
+	l.depth++
+	if l.maxDepth > 0 && l.depth > l.maxDepth {
+		panic(abortPanic{&DepthLimitError{input.Row(), input.Col(), l.maxDepth}})
+	}
+	r, err := p.runHooks(input)
+	l.depth--
+	return r, err
+}
+
+func newParseLimits(ctx context.Context, options []ParseOption) *parseLimits {
+	l := &parseLimits{ctx: ctx}
+	for _, option := range options {
+		option(l)
+	}
+	return l
+}
+
+// parse 在资源限制下解析input返回的输入直到末尾，input在恢复超限错误的范围内调用
+func (l *parseLimits) parse(p *Parser, input func() Input) (result any, err error) {
+	if err := l.ctx.Err(); err != nil {
+		return nil, &CanceledError{1, 1, err}
+	}
+	defer catchAbort(&err)
+	i := input()
+	i.ctx = &parseContext{limits: l}
+	return p.parseToEnd(i)
+}
+
+// ParseToEndContext 与ParseToEnd相同，但在ctx取消时中止解析，并按options限制资源的使用，
+// 超出限制时返回CanceledError、DepthLimitError、StepLimitError或InputSizeError
+func (p Parser) ParseToEndContext(ctx context.Context, s string, options ...ParseOption) (any, error) {
+	l := newParseLimits(ctx, options)
+	if l.maxInputSize > 0 && len(s) > l.maxInputSize {
+		return nil, &InputSizeError{len(s), l.maxInputSize}
+	}
+	if err := validateUTF8(s); err != nil {
+		return nil, err
+	}
+	return l.parse(&p, func() Input {
+		return CreateInput(s)
+	})
+}
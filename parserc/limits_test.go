@@ -0,0 +1,89 @@
+package parserc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func nestedGrammar() *Parser {
+	p := NewParser()
+	p.Set(Skip(Ch('(')).And(p).Skip(Ch(')')).Or(Ch('x')))
+	return p
+}
+
+func TestParseToEndContext(t *testing.T) {
+	r, err := nestedGrammar().ParseToEndContext(context.Background(), "((x))")
+	assert.Nil(t, err)
+	assert.Equal(t, 'x', r)
+	_, err = nestedGrammar().ParseToEndContext(context.Background(), "((x)")
+	assert.NotNil(t, err)
+	_, err = nestedGrammar().ParseToEndContext(context.Background(), "((x))y")
+	assert.Equal(t, "parse error at row 1, col 6: end of input not reached", err.Error())
+}
+
+func TestParseToEndContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := nestedGrammar().ParseToEndContext(ctx, "x")
+	var canceled *CanceledError
+	assert.True(t, errors.As(err, &canceled))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	slow := Ch('a').Map(func(r any) any {
+		time.Sleep(time.Millisecond)
+		return r
+	}).Many()
+	_, err = slow.ParseToEndContext(ctx, strings.Repeat("a", 2000))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestParseToEndContextMaxDepth(t *testing.T) {
+	p := nestedGrammar()
+	_, err := p.ParseToEndContext(context.Background(), "(((x)))", WithMaxDepth(4))
+	assert.Nil(t, err)
+	_, err = p.ParseToEndContext(context.Background(), "((((x))))", WithMaxDepth(4))
+	var depthErr *DepthLimitError
+	assert.True(t, errors.As(err, &depthErr))
+	assert.Equal(t, 4, depthErr.Limit)
+	assert.Equal(t, "parse error at row 1, col 5: maximum recursion depth 4 exceeded", err.Error())
+}
+
+func TestParseToEndContextMaxSteps(t *testing.T) {
+	p := Ch('a').Many()
+	_, err := p.ParseToEndContext(context.Background(), "aaa", WithMaxSteps(10))
+	assert.Nil(t, err)
+	_, err = p.ParseToEndContext(context.Background(), strings.Repeat("a", 20), WithMaxSteps(10))
+	var stepErr *StepLimitError
+	assert.True(t, errors.As(err, &stepErr))
+	assert.Equal(t, 10, stepErr.Limit)
+}
+
+func TestParseToEndContextMaxInputSize(t *testing.T) {
+	_, err := nestedGrammar().ParseToEndContext(context.Background(), "(x)", WithMaxInputSize(3))
+	assert.Nil(t, err)
+	_, err = nestedGrammar().ParseToEndContext(context.Background(), "((x))", WithMaxInputSize(3))
+	var sizeErr *InputSizeError
+	assert.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, "input size 5 exceeds maximum 3", err.Error())
+}
+
+func TestParseToEndContextFatal(t *testing.T) {
+	assert.Panics(t, func() {
+		_, _ = Ch('a').Fatal().ParseToEndContext(context.Background(), "b")
+	})
+}
+
+func TestParseToEndContextWithHooks(t *testing.T) {
+	var sb strings.Builder
+	p := traceGrammar().Trace(&sb)
+	r, err := p.ParseToEndContext(context.Background(), "(1)", WithMaxDepth(10))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{'1'}, r)
+	assert.Contains(t, sb.String(), "match expr")
+}
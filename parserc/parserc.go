@@ -1,8 +1,14 @@
 package parserc
 
 import (
-	"errors"
 	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ParseResult 解析结果
@@ -18,24 +24,214 @@ type ParseFunc func(Input) (ParseResult, error)
 
 // Parser 解析器
 type Parser struct {
-	parse ParseFunc
+	parse    ParseFunc
+	kind     string        // 组合子类型
+	args     []any         // 组合子参数
+	children []*Parser     // 子解析器
+	name     string        // 规则名称
+	mapper   func(any) any // Map组合子的转换函数，供编译为字节码时使用
+	origin   *Parser       // Named创建的副本对应的原解析器，解析函数中记录的选择分支属于原解析器
+}
+
+// describe 以指定的组合子类型、参数和子解析器描述p，用于由其他组合子构造而成的组合子
+func describe(p *Parser, kind string, args []any, children ...*Parser) *Parser {
+	return &Parser{parse: p.parse, kind: kind, args: args, children: children}
+}
+
+// parseContext 一次解析过程中共享的状态，随Input传递
+type parseContext struct {
+	hooks   []ruleHook   // 命名规则的进入、退出回调
+	limits  *parseLimits // 资源限制，为nil时不限制
+	partial bool         // 输入是否不完整，即末尾之后可能还有数据
+}
+
+// ruleHook 命名规则的进入、退出回调
+type ruleHook interface {
+	enter(p *Parser, input Input)
+	exit(p *Parser, input Input, r ParseResult, err error)
+}
+
+// branchHook 选择分支的回调，index为Or、OneOf成功的分支序号，或Opt的路径（0为匹配，1为默认值）
+type branchHook interface {
+	branch(p *Parser, index int)
+}
+
+func (c *parseContext) branch(p *Parser, index int) {
+	if c == nil {
+		return
+	}
+	for _, h := range c.hooks {
+		if bh, ok := h.(branchHook); ok {
+			bh.branch(p, index)
+		}
+	}
+}
+
+// run 执行解析器，组合子应通过run而不是parse调用子解析器
+func (p *Parser) run(input Input) (ParseResult, error) {
+	if input.ctx == nil {
+		if p.name == "" {
+			return p.parse(input)
+		}
+		return p.runNamed(input)
+	}
+	if input.ctx.limits != nil {
+		return input.ctx.limits.run(p, input)
+	}
+	return p.runHooks(input)
+}
+
+// runNamed 执行命名的解析器，在开始位置失败时以期望该规则代替子解析器的错误
+func (p *Parser) runNamed(input Input) (ParseResult, error) {
+	r, err := p.parse(input)
+	if f, ok := err.(*parseFailure); ok && f.at(input) {
+		err = parseError(input, "expected "+p.name)
+	}
+	return r, err
+}
+
+func (p *Parser) runHooks(input Input) (ParseResult, error) {
+	if p.name == "" {
+		return p.parse(input)
+	}
+	if len(input.ctx.hooks) == 0 {
+		return p.runNamed(input)
+	}
+	hooks := input.ctx.hooks
+	for _, h := range hooks {
+		h.enter(p, input)
+	}
+	r, err := p.runNamed(input)
+	for k := len(hooks) - 1; k >= 0; k-- {
+		hooks[k].exit(p, input, r, err)
+	}
+	return r, err
+}
+
+// withHook 返回一个解析器，该解析器每次执行时通过start创建回调，使p及其命名子规则的执行过程触发该回调，
+// 执行完毕后调用finish
+func withHook(p *Parser, kind string, start func() ruleHook, finish func(ruleHook)) *Parser {
+	return &Parser{kind: kind, children: []*Parser{p}, parse: func(input Input) (ParseResult, error) {
+		outer := input.ctx
+		h := start()
+		ctx := &parseContext{}
+		if outer != nil {
+			*ctx = *outer
+		}
+		ctx.hooks = append(append([]ruleHook{}, ctx.hooks...), h)
+		input.ctx = ctx
+		if p.name == "" {
+			h.enter(p, input)
+		}
+		r, err := p.run(input)
+		if p.name == "" {
+			h.exit(p, input, r, err)
+		}
+		finish(h)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		r.Remain.ctx = outer
+		return r, nil
+	}}
+}
+
+func runeArgs(cs []rune) []any {
+	args := make([]any, 0, len(cs))
+	for _, c := range cs {
+		args = append(args, c)
+	}
+	return args
+}
+
+func stringArgs(ss []string) []any {
+	args := make([]any, 0, len(ss))
+	for _, s := range ss {
+		args = append(args, s)
+	}
+	return args
+}
+
+// parseFailure 解析错误，记录失败的位置，供命名规则判断失败是否发生在规则开始处
+type parseFailure struct {
+	index  int     // 失败位置，含义与Input.index相同
+	offset int     // 失败位置，含义与Input.offset相同
+	src    *source // 失败位置所在的输入来源
+	msg    string
+}
+
+func (e *parseFailure) Error() string {
+	return e.msg
+}
+
+// at 判断失败是否发生在input的位置
+func (e *parseFailure) at(input Input) bool {
+	return e.index == input.index && e.src == input.src
 }
 
 func parseError(input Input, msg string) error {
-	return errors.New(fmt.Sprintf("parse error at row %d, col %d: %s", input.Row(), input.Col(), msg))
+	pos := fmt.Sprintf("row %d, col %d", input.Row(), input.Col())
+	if input.binary {
+		// 字节输入的列号从1开始随每个字节递增，增量解析时跨越多次解析累计
+		pos = fmt.Sprintf("offset %d", input.col-1)
+	}
+	if input.src != nil {
+		return &parseFailure{input.index, input.offset, input.src, fmt.Sprintf("parse error in %s at %s: %s%s", input.src.name, pos, msg, input.src.chain())}
+	}
+	return &parseFailure{input.index, input.offset, nil, fmt.Sprintf("parse error at %s: %s", pos, msg)}
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func runeEqual(c1 rune, c2 rune, fold bool) bool {
+	if c1 == c2 {
+		return true
+	}
+	if !fold {
+		return false
+	}
+	for c := unicode.SimpleFold(c1); c != c1; c = unicode.SimpleFold(c) {
+		if c == c2 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeResult(sb *strings.Builder, r any) {
+	switch v := r.(type) {
+	case rune:
+		sb.WriteRune(v)
+	case string:
+		sb.WriteString(v)
+	default:
+		sb.WriteString(fmt.Sprint(v))
+	}
+}
+
+// Succeed 不消耗输入直接成功，解析结果为result
+func Succeed(result any) *Parser {
+	return &Parser{kind: "Succeed", args: []any{result}, parse: func(input Input) (ParseResult, error) {
+		return ParseResult{result, input}, nil
+	}}
 }
 
 // Fail 直接失败
 func Fail(msg string) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
+	return &Parser{kind: "Fail", args: []any{msg}, parse: func(input Input) (ParseResult, error) {
 		return emptyParseResult, parseError(input, msg)
 	}}
 }
 
 // Any 匹配任意字符
 func Any() *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		if input.End() {
+	return &Parser{kind: "Any", parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
 			return emptyParseResult, parseError(input, "unexpected end of input")
 		}
 		c := input.Current()
@@ -43,10 +239,23 @@ func Any() *Parser {
 	}}
 }
 
+// End 匹配输入流末尾
+func End() *Parser {
+	return &Parser{kind: "End", parse: func(input Input) (ParseResult, error) {
+		if !input.eof() {
+			return emptyParseResult, parseError(input, "expected end of input")
+		}
+		return ParseResult{nil, input}, nil
+	}}
+}
+
 // Ch 匹配指定字符
 func Ch(c rune) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		if input.End() {
+	return &Parser{kind: "Ch", args: []any{c}, parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
 			return emptyParseResult, parseError(input, "unexpected end of input")
 		}
 		ch := input.Current()
@@ -63,8 +272,11 @@ func Chs(chs ...rune) *Parser {
 	for _, c := range chs {
 		set[c] = true
 	}
-	return &Parser{func(input Input) (ParseResult, error) {
-		if input.End() {
+	return &Parser{kind: "Chs", args: runeArgs(chs), parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
 			return emptyParseResult, parseError(input, "unexpected end of input")
 		}
 		c := input.Current()
@@ -78,8 +290,11 @@ func Chs(chs ...rune) *Parser {
 
 // Not 匹配不等于指定字符的字符
 func Not(c rune) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		if input.End() {
+	return &Parser{kind: "Not", args: []any{c}, parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
 			return emptyParseResult, parseError(input, "unexpected end of input")
 		}
 		ch := input.Current()
@@ -92,8 +307,11 @@ func Not(c rune) *Parser {
 
 // Range 匹配指定范围内的字符
 func Range(c1 rune, c2 rune) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		if input.End() {
+	return &Parser{kind: "Range", args: []any{c1, c2}, parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
 			return emptyParseResult, parseError(input, "unexpected end of input")
 		}
 		c := input.Current()
@@ -106,10 +324,13 @@ func Range(c1 rune, c2 rune) *Parser {
 
 // Str 匹配字符串前缀
 func Str(s string) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
+	return &Parser{kind: "Str", args: []any{s}, parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
 		i := input
 		for _, c := range s {
-			if i.End() || i.Current() != c {
+			if i.eof() || i.Current() != c {
 				return emptyParseResult, parseError(input, fmt.Sprintf("expected %s", s))
 			}
 			i = i.Next()
@@ -118,10 +339,326 @@ func Str(s string) *Parser {
 	}}
 }
 
+// ChFold 忽略大小写匹配指定字符，解析结果为c本身
+func ChFold(c rune) *Parser {
+	return describe(chFold(c, false), "ChFold", []any{c})
+}
+
+// ChFoldRaw 忽略大小写匹配指定字符，解析结果为输入中的原始字符
+func ChFoldRaw(c rune) *Parser {
+	return describe(chFold(c, true), "ChFoldRaw", []any{c})
+}
+
+func chFold(c rune, raw bool) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		if input.eof() {
+			return emptyParseResult, parseError(input, "unexpected end of input")
+		}
+		ch := input.Current()
+		if !runeEqual(ch, c, true) {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected %c", c))
+		}
+		if raw {
+			return ParseResult{ch, input.Next()}, nil
+		}
+		return ParseResult{c, input.Next()}, nil
+	}}
+}
+
+// StrFold 忽略大小写匹配字符串前缀，解析结果为s本身
+func StrFold(s string) *Parser {
+	return describe(strFold(s, false), "StrFold", []any{s})
+}
+
+// StrFoldRaw 忽略大小写匹配字符串前缀，解析结果为输入中的原始字符串
+func StrFoldRaw(s string) *Parser {
+	return describe(strFold(s, true), "StrFoldRaw", []any{s})
+}
+
+func strFold(s string, raw bool) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		var sb strings.Builder
+		i := input
+		for _, c := range s {
+			if i.eof() || !runeEqual(i.Current(), c, true) {
+				return emptyParseResult, parseError(input, fmt.Sprintf("expected %s", s))
+			}
+			sb.WriteRune(i.Current())
+			i = i.Next()
+		}
+		if raw {
+			return ParseResult{sb.String(), i}, nil
+		}
+		return ParseResult{s, i}, nil
+	}}
+}
+
+// Keyword 匹配关键字，关键字之后不能紧跟标识符字符
+func Keyword(word string) *Parser {
+	return describe(keyword(word, false), "Keyword", []any{word})
+}
+
+// KeywordFold 忽略大小写匹配关键字，解析结果为word本身
+func KeywordFold(word string) *Parser {
+	return describe(keyword(word, true), "KeywordFold", []any{word})
+}
+
+func keyword(word string, fold bool) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		i := input
+		for _, c := range word {
+			if i.eof() || !runeEqual(i.Current(), c, fold) {
+				return emptyParseResult, parseError(input, fmt.Sprintf("expected keyword %s", word))
+			}
+			i = i.Next()
+		}
+		if !i.eof() && isIdentRune(i.Current()) {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected keyword %s", word))
+		}
+		return ParseResult{word, i}, nil
+	}}
+}
+
+// Identifier 匹配由start开头、后接零个或多个rest的标识符，标识符不能是reserved中的保留字
+func Identifier(start *Parser, rest *Parser, reserved ...string) *Parser {
+	return describe(identifier(start, rest, reserved, false), "Identifier", stringArgs(reserved), start, rest)
+}
+
+// IdentifierFold 与Identifier相同，但忽略大小写比较保留字
+func IdentifierFold(start *Parser, rest *Parser, reserved ...string) *Parser {
+	return describe(identifier(start, rest, reserved, true), "IdentifierFold", stringArgs(reserved), start, rest)
+}
+
+func identifier(start *Parser, rest *Parser, reserved []string, fold bool) *Parser {
+	p := start.And(rest.Many()).Map(func(p any) any {
+		var sb strings.Builder
+		writeResult(&sb, p.(Pair).First)
+		for _, r := range p.(Pair).Second.([]any) {
+			writeResult(&sb, r)
+		}
+		return sb.String()
+	})
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		r, err := p.run(input)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		id := r.Result.(string)
+		for _, word := range reserved {
+			if id == word || fold && strings.EqualFold(id, word) {
+				return emptyParseResult, parseError(input, fmt.Sprintf("reserved keyword %s", id))
+			}
+		}
+		return r, nil
+	}}
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	word     string
+	value    any
+	terminal bool
+}
+
+func (n *trieNode) insert(word string, value any) {
+	for _, c := range word {
+		child, exist := n.children[c]
+		if !exist {
+			child = &trieNode{children: make(map[rune]*trieNode)}
+			n.children[c] = child
+		}
+		n = child
+	}
+	n.word = word
+	n.value = value
+	n.terminal = true
+}
+
+func literals(root *trieNode, expected string) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		if err := requireChars(input); err != nil {
+			return emptyParseResult, err
+		}
+		var matched *trieNode
+		var remain Input
+		if root.terminal {
+			// 空字符串作为候选词时，没有更长的匹配也能成功
+			matched, remain = root, input
+		}
+		n, i := root, input
+		for !i.eof() {
+			child, exist := n.children[i.Current()]
+			if !exist {
+				break
+			}
+			n, i = child, i.Next()
+			if n.terminal {
+				matched, remain = n, i
+			}
+		}
+		if matched == nil {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected one of %s", expected))
+		}
+		return ParseResult{matched.value, remain}, nil
+	}}
+}
+
+// Regex 从当前位置开始匹配正则表达式，解析结果为匹配到的文本，适用于字符串输入与字节输入。pattern有误时panic
+func Regex(pattern string) *Parser {
+	re := regexp.MustCompile(`^(?:` + pattern + `)`)
+	prog := regexProg(re.String())
+	return &Parser{kind: "Regex", args: []any{pattern}, parse: func(input Input) (ParseResult, error) {
+		if input.chunk != nil || input.tokens != nil {
+			return emptyParseResult, parseError(input, "regex parser requires string or byte input")
+		}
+		partial := input.ctx != nil && input.ctx.partial
+		rest := input.str[input.bytePos():]
+		loc := re.FindStringIndex(rest)
+		if loc == nil {
+			if partial && isRegexPrefix(prog, rest) {
+				// 缓冲的数据是某个匹配的前缀，之后的数据可能使匹配成功
+				end := input
+				for !end.End() {
+					end = end.Next()
+				}
+				end.incomplete()
+			}
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected /%s/", pattern))
+		}
+		i := input
+		for end := input.bytePos() + loc[1]; i.bytePos() < end; {
+			i = i.Next()
+		}
+		if partial && loc[1] == len(rest) {
+			// 匹配到达末尾时，不完整的输入之后的数据可能使匹配更长
+			i.incomplete()
+		}
+		return ParseResult{rest[:loc[1]], i}, nil
+	}}
+}
+
+// regexProg 将正则表达式编译为regexp/syntax的指令，用于判断文本是否为某个匹配的前缀
+func regexProg(expr string) *syntax.Prog {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		panic(err)
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		panic(err)
+	}
+	return prog
+}
+
+// isRegexPrefix 判断s之后追加数据时是否可能匹配prog，即以NFA依次读取s中的字符后是否仍有线程存活。
+// s的末尾之后的字符未知，此时零宽断言均视为可能成立
+func isRegexPrefix(prog *syntax.Prog, s string) bool {
+	visited := make([]bool, len(prog.Inst))
+	var add func(threads []uint32, pc uint32, flags syntax.EmptyOp, atEnd bool) []uint32
+	add = func(threads []uint32, pc uint32, flags syntax.EmptyOp, atEnd bool) []uint32 {
+		if visited[pc] {
+			return threads
+		}
+		visited[pc] = true
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			threads = add(threads, inst.Out, flags, atEnd)
+			return add(threads, inst.Arg, flags, atEnd)
+		case syntax.InstCapture, syntax.InstNop:
+			return add(threads, inst.Out, flags, atEnd)
+		case syntax.InstEmptyWidth:
+			if atEnd || syntax.EmptyOp(inst.Arg)&^flags == 0 {
+				return add(threads, inst.Out, flags, atEnd)
+			}
+			return threads
+		case syntax.InstFail:
+			return threads
+		}
+		return append(threads, pc)
+	}
+	// flags 位于pos之前的字符为prev时，pos处成立的零宽断言
+	flags := func(prev rune, pos int) syntax.EmptyOp {
+		next := rune(-1)
+		if pos < len(s) {
+			next, _ = utf8.DecodeRuneInString(s[pos:])
+		}
+		return syntax.EmptyOpContext(prev, next)
+	}
+	threads := add(nil, uint32(prog.Start), flags(-1, 0), len(s) == 0)
+	for pos := 0; pos < len(s) && len(threads) > 0; {
+		c, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+		for k := range visited {
+			visited[k] = false
+		}
+		var next []uint32
+		for _, pc := range threads {
+			if inst := &prog.Inst[pc]; inst.Op != syntax.InstMatch && matchInst(inst, c) {
+				next = add(next, inst.Out, flags(c, pos), pos == len(s))
+			}
+		}
+		threads = next
+	}
+	return len(threads) > 0
+}
+
+// matchInst 判断读取字符的指令能否读取c
+func matchInst(inst *syntax.Inst, c rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return c != '\n'
+	}
+	return inst.MatchRune(c)
+}
+
+// maxExpectedWords 错误信息中最多列出的候选词个数
+const maxExpectedWords = 8
+
+// expectedWords 错误信息中的候选词列表，超出maxExpectedWords的部分只给出个数
+func expectedWords(words []string) string {
+	if len(words) <= maxExpectedWords {
+		return strings.Join(words, ", ")
+	}
+	return fmt.Sprintf("%s, ... (%d more)", strings.Join(words[:maxExpectedWords], ", "), len(words)-maxExpectedWords)
+}
+
+// Literals 使用字典树匹配多个字符串中最长的一个，解析结果为匹配到的字符串。空字符串作为候选词时可以不消耗输入而成功
+func Literals(words ...string) *Parser {
+	root := &trieNode{children: make(map[rune]*trieNode)}
+	for _, w := range words {
+		root.insert(w, w)
+	}
+	return describe(literals(root, expectedWords(words)), "Literals", stringArgs(words))
+}
+
+// LiteralsMap 使用字典树匹配table中最长的键，解析结果为该键对应的值
+func LiteralsMap(table map[string]any) *Parser {
+	root := &trieNode{children: make(map[rune]*trieNode)}
+	words := make([]string, 0, len(table))
+	for w, v := range table {
+		root.insert(w, v)
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return describe(literals(root, expectedWords(words)), "LiteralsMap", stringArgs(words))
+}
+
 // Map 转换解析结果
 func Map(p *Parser, mapper func(any) any) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		r, err := p.parse(input)
+	return &Parser{kind: "Map", children: []*Parser{p}, mapper: mapper, parse: func(input Input) (ParseResult, error) {
+		r, err := p.run(input)
 		if err != nil {
 			return emptyParseResult, err
 		}
@@ -131,12 +668,12 @@ func Map(p *Parser, mapper func(any) any) *Parser {
 
 // And 连接两个解析器
 func And(lhs *Parser, rhs *Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		r1, err := lhs.parse(input)
+	return &Parser{kind: "And", children: []*Parser{lhs, rhs}, parse: func(input Input) (ParseResult, error) {
+		r1, err := lhs.run(input)
 		if err != nil {
 			return emptyParseResult, err
 		}
-		r2, err := rhs.parse(r1.Remain)
+		r2, err := rhs.run(r1.Remain)
 		if err != nil {
 			return emptyParseResult, err
 		}
@@ -146,10 +683,10 @@ func And(lhs *Parser, rhs *Parser) *Parser {
 
 // Seq 连接多个解析器
 func Seq(parsers ...*Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
+	return &Parser{kind: "Seq", children: parsers, parse: func(input Input) (ParseResult, error) {
 		rs := make([]any, 0)
 		for _, p := range parsers {
-			r, err := p.parse(input)
+			r, err := p.run(input)
 			if err != nil {
 				return emptyParseResult, err
 			}
@@ -162,40 +699,62 @@ func Seq(parsers ...*Parser) *Parser {
 
 // Or 有序选择两个解析器
 func Or(lhs *Parser, rhs *Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		r, err := lhs.parse(input)
-		if err == nil {
-			return r, nil
-		}
-		r, err = rhs.parse(input)
-		if err != nil {
-			return emptyParseResult, err
-		}
-		return r, nil
-	}}
+	return choice("Or", []*Parser{lhs, rhs})
 }
 
-// OneOf 有序选择多个解析器
+// OneOf 有序选择多个解析器。第一次解析时计算每个分支的FIRST集，之后根据当前字符直接跳转到可能成功的分支，
+// 各分支的FIRST集互不相交时只需尝试一个分支，相交的部分仍按顺序依次尝试
 func OneOf(p1 *Parser, p2 *Parser, parsers ...*Parser) *Parser {
-	p := Or(p1, p2)
-	for _, pp := range parsers {
-		p = Or(p, pp)
+	return choice("OneOf", append([]*Parser{p1, p2}, parsers...))
+}
+
+func choice(kind string, parsers []*Parser) *Parser {
+	p := &Parser{kind: kind, children: parsers}
+	var once sync.Once
+	var table *dispatchTable
+	p.parse = func(input Input) (ParseResult, error) {
+		var err error
+		// Trace、Profile需要观察每个分支的尝试过程，此时仍依次尝试所有分支
+		if input.tokens == nil && (input.ctx == nil || len(input.ctx.hooks) == 0) && !input.End() {
+			// 第一次解析时计算FIRST集，此时所有NewParser均已设置
+			once.Do(func() {
+				table = newDispatchTable(parsers)
+			})
+			for _, k := range table.lookup(input.Current()) {
+				var r ParseResult
+				r, err = parsers[k].run(input)
+				if err == nil {
+					input.ctx.branch(p, k)
+					return r, nil
+				}
+			}
+			return emptyParseResult, err
+		}
+		for k, pp := range parsers {
+			var r ParseResult
+			r, err = pp.run(input)
+			if err == nil {
+				input.ctx.branch(p, k)
+				return r, nil
+			}
+		}
+		return emptyParseResult, err
 	}
 	return p
 }
 
 // SkipFirst 连接两个解析器，并丢弃第一个解析器的结果
 func SkipFirst(p1 *Parser, p2 *Parser) *Parser {
-	return p1.And(p2).Map(func(p any) any {
+	return describe(p1.And(p2).Map(func(p any) any {
 		return p.(Pair).Second
-	})
+	}), "SkipFirst", nil, p1, p2)
 }
 
 // SkipSecond 连接两个解析器，并丢弃第二个解析器的结果
 func SkipSecond(p1 *Parser, p2 *Parser) *Parser {
-	return p1.And(p2).Map(func(p any) any {
+	return describe(p1.And(p2).Map(func(p any) any {
 		return p.(Pair).First
-	})
+	}), "SkipSecond", nil, p1, p2)
 }
 
 type SkipWrapper struct {
@@ -211,10 +770,10 @@ func Skip(lhs *Parser) SkipWrapper {
 
 // Many 应用指定解析器零次或多次
 func Many(p *Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
+	return &Parser{kind: "Many", children: []*Parser{p}, parse: func(input Input) (ParseResult, error) {
 		rs := make([]any, 0)
 		for {
-			r, err := p.parse(input)
+			r, err := p.run(input)
 			if err != nil {
 				break
 			}
@@ -227,53 +786,212 @@ func Many(p *Parser) *Parser {
 
 // Many1 应用指定解析器一次或多次
 func Many1(p *Parser) *Parser {
-	return p.And(p.Many()).Map(func(p any) any {
+	return describe(p.And(p.Many()).Map(func(p any) any {
 		pair := p.(Pair)
 		rs := make([]any, 0)
 		rs = append(rs, pair.First)
 		rs = append(rs, pair.Second.([]any)...)
 		return rs
-	})
+	}), "Many1", nil, p)
+}
+
+func repeat(min int, max int, p *Parser) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		rs := make([]any, 0)
+		i := input
+		for max < 0 || len(rs) < max {
+			r, err := p.run(i)
+			if err != nil {
+				break
+			}
+			rs = append(rs, r.Result)
+			i = r.Remain
+		}
+		if len(rs) < min {
+			quantifier := "at least"
+			if min == max {
+				quantifier = "exactly"
+			}
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected %s %d repetitions, found %d", quantifier, min, len(rs)))
+		}
+		return ParseResult{rs, i}, nil
+	}}
+}
+
+// checkCount 检查重复次数，次数为负数时panic
+func checkCount(kind string, n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("parserc.%s: negative repetition count %d", kind, n))
+	}
+}
+
+// Times 应用指定解析器恰好n次。n为负数时panic
+func Times(n int, p *Parser) *Parser {
+	checkCount("Times", n)
+	return describe(repeat(n, n, p), "Times", []any{n}, p)
+}
+
+// AtLeast 应用指定解析器至少n次。n为负数时panic
+func AtLeast(n int, p *Parser) *Parser {
+	checkCount("AtLeast", n)
+	return describe(repeat(n, -1, p), "AtLeast", []any{n}, p)
+}
+
+// AtMost 应用指定解析器至多n次。n为负数时panic
+func AtMost(n int, p *Parser) *Parser {
+	checkCount("AtMost", n)
+	return describe(repeat(0, n, p), "AtMost", []any{n}, p)
+}
+
+// Repeat 应用指定解析器至少min次，至多max次。min为负数或大于max时panic
+func Repeat(min int, max int, p *Parser) *Parser {
+	checkCount("Repeat", min)
+	if min > max {
+		panic(fmt.Sprintf("parserc.Repeat: min %d greater than max %d", min, max))
+	}
+	return describe(repeat(min, max, p), "Repeat", []any{min, max}, p)
 }
 
 // Opt 尝试应用解析器，并在失败时返回默认值
 func Opt(p *Parser, defaultValue any) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		r, err := p.parse(input)
+	opt := &Parser{kind: "Opt", args: []any{defaultValue}, children: []*Parser{p}}
+	opt.parse = func(input Input) (ParseResult, error) {
+		r, err := p.run(input)
 		if err != nil {
+			input.ctx.branch(opt, 1)
 			return ParseResult{defaultValue, input}, nil
 		}
+		input.ctx.branch(opt, 0)
 		return r, nil
-	}}
+	}
+	return opt
 }
 
 // Peek 根据probe的执行成功与否，选择执行success或failed
 func Peek(probe *Parser, success *Parser, failed *Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		_, err := probe.parse(input)
+	return &Parser{kind: "Peek", children: []*Parser{probe, success, failed}, parse: func(input Input) (ParseResult, error) {
+		_, err := probe.run(input)
 		if err != nil {
-			return failed.parse(input)
+			return failed.run(input)
 		}
-		return success.parse(input)
+		return success.run(input)
 	}}
 }
 
 // Separate 匹配被给定分隔符分隔的输入
 func Separate(delimiter *Parser, p *Parser) *Parser {
-	return p.And(Skip(delimiter).And(p).Many()).Map(func(p any) any {
+	return describe(p.And(Skip(delimiter).And(p).Many()).Map(func(p any) any {
 		var result []any
 		result = append(result, p.(Pair).First)
 		for _, e := range p.(Pair).Second.([]any) {
 			result = append(result, e)
 		}
 		return result
-	})
+	}), "Separate", nil, delimiter, p)
+}
+
+func sepBy(delimiter *Parser, p *Parser, min int, keep bool) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		rs := make([]any, 0)
+		r, err := p.run(input)
+		if err != nil {
+			if min > 0 {
+				return emptyParseResult, err
+			}
+			return ParseResult{rs, input}, nil
+		}
+		rs = append(rs, r.Result)
+		i := r.Remain
+		for {
+			d, err := delimiter.run(i)
+			if err != nil {
+				break
+			}
+			r, err := p.run(d.Remain)
+			if err != nil {
+				break
+			}
+			if keep {
+				rs = append(rs, d.Result)
+			}
+			rs = append(rs, r.Result)
+			i = r.Remain
+		}
+		return ParseResult{rs, i}, nil
+	}}
+}
+
+func endBy(delimiter *Parser, p *Parser, trailingRequired bool, keep bool) *Parser {
+	return &Parser{parse: func(input Input) (ParseResult, error) {
+		rs := make([]any, 0)
+		i := input
+		for {
+			r, err := p.run(i)
+			if err != nil {
+				break
+			}
+			d, err := delimiter.run(r.Remain)
+			if err != nil {
+				if !trailingRequired {
+					rs = append(rs, r.Result)
+					i = r.Remain
+				}
+				break
+			}
+			rs = append(rs, r.Result)
+			if keep {
+				rs = append(rs, d.Result)
+			}
+			i = d.Remain
+		}
+		return ParseResult{rs, i}, nil
+	}}
+}
+
+// SepBy 匹配零个或多个被分隔符分隔的元素
+func SepBy(delimiter *Parser, p *Parser) *Parser {
+	return describe(sepBy(delimiter, p, 0, false), "SepBy", nil, delimiter, p)
+}
+
+// SepByKeep 与SepBy相同，但在解析结果中保留分隔符
+func SepByKeep(delimiter *Parser, p *Parser) *Parser {
+	return describe(sepBy(delimiter, p, 0, true), "SepByKeep", nil, delimiter, p)
+}
+
+// SepBy1 匹配一个或多个被分隔符分隔的元素
+func SepBy1(delimiter *Parser, p *Parser) *Parser {
+	return describe(sepBy(delimiter, p, 1, false), "SepBy1", nil, delimiter, p)
+}
+
+// SepBy1Keep 与SepBy1相同，但在解析结果中保留分隔符
+func SepBy1Keep(delimiter *Parser, p *Parser) *Parser {
+	return describe(sepBy(delimiter, p, 1, true), "SepBy1Keep", nil, delimiter, p)
+}
+
+// SepEndBy 匹配零个或多个被分隔符分隔的元素，允许末尾存在一个分隔符
+func SepEndBy(delimiter *Parser, p *Parser) *Parser {
+	return describe(endBy(delimiter, p, false, false), "SepEndBy", nil, delimiter, p)
+}
+
+// SepEndByKeep 与SepEndBy相同，但在解析结果中保留分隔符
+func SepEndByKeep(delimiter *Parser, p *Parser) *Parser {
+	return describe(endBy(delimiter, p, false, true), "SepEndByKeep", nil, delimiter, p)
+}
+
+// EndBy 匹配零个或多个元素，每个元素之后必须紧跟分隔符
+func EndBy(delimiter *Parser, p *Parser) *Parser {
+	return describe(endBy(delimiter, p, true, false), "EndBy", nil, delimiter, p)
+}
+
+// EndByKeep 与EndBy相同，但在解析结果中保留分隔符
+func EndByKeep(delimiter *Parser, p *Parser) *Parser {
+	return describe(endBy(delimiter, p, true, true), "EndByKeep", nil, delimiter, p)
 }
 
 // Fatal 指定解析器解析失败时，抛出关键错误
 func Fatal(p *Parser) *Parser {
-	return &Parser{func(input Input) (ParseResult, error) {
-		r, e := p.parse(input)
+	return &Parser{kind: "Fatal", children: []*Parser{p}, parse: func(input Input) (ParseResult, error) {
+		r, e := p.run(input)
 		if e != nil {
 			panic(e)
 		}
@@ -283,12 +1001,26 @@ func Fatal(p *Parser) *Parser {
 
 // NewParser 创建空解析器，该解析器随后通过Set方法设置
 func NewParser() *Parser {
-	return &Parser{nil}
+	return &Parser{kind: "NewParser"}
+}
+
+// Parse 从指定输入流开头解析，不要求到达输入流末尾
+func (p *Parser) Parse(input Input) (r ParseResult, err error) {
+	defer catchAbort(&err)
+	return p.run(input)
 }
 
-// ParseToEnd 解析输入直到末尾
+// ParseToEnd 解析输入直到末尾，s包含无效的UTF-8编码时返回EncodingError
 func (p Parser) ParseToEnd(s string) (any, error) {
-	r, err := p.parse(CreateInput(s))
+	if err := validateUTF8(s); err != nil {
+		return nil, err
+	}
+	return p.parseToEnd(CreateInput(s))
+}
+
+func (p *Parser) parseToEnd(input Input) (result any, err error) {
+	defer catchAbort(&err)
+	r, err := p.run(input)
 	if err != nil {
 		return nil, err
 	}
@@ -302,6 +1034,18 @@ func (p Parser) ParseToEnd(s string) (any, error) {
 // Set 设置解析器
 func (p *Parser) Set(parser *Parser) {
 	p.parse = parser.parse
+	p.children = []*Parser{parser}
+}
+
+// Named 返回当前解析器以name命名的副本，不修改当前解析器。命名的解析器在导出语法时作为单独的规则，
+// 在开始位置失败时报告"expected name"。为NewParser命名时，应对返回的解析器调用Set
+func (p *Parser) Named(name string) *Parser {
+	named := *p
+	named.name = name
+	if named.origin == nil {
+		named.origin = p
+	}
+	return &named
 }
 
 // And 连接另一个解析器
@@ -336,14 +1080,34 @@ func (p *Parser) Skip(rhs *Parser) *Parser {
 
 // Surround 在当前解析器周围应用另一个解析器
 func (p *Parser) Surround(parser *Parser) *Parser {
-	return Seq(parser, p, parser).Map(func(rs any) any {
+	return describe(Seq(parser, p, parser).Map(func(rs any) any {
 		return rs.([]any)[1]
-	})
+	}), "Surround", nil, p, parser)
 }
 
 // ManyUntil 应用当前解析器零次或多次，直到指定解析器执行成功
 func (p *Parser) ManyUntil(until *Parser) *Parser {
-	return Peek(until, Fail("no error message"), p).Many()
+	return describe(Peek(until, Fail("no error message"), p).Many(), "ManyUntil", nil, p, until)
+}
+
+// Times 应用当前解析器恰好n次
+func (p *Parser) Times(n int) *Parser {
+	return Times(n, p)
+}
+
+// AtLeast 应用当前解析器至少n次
+func (p *Parser) AtLeast(n int) *Parser {
+	return AtLeast(n, p)
+}
+
+// AtMost 应用当前解析器至多n次
+func (p *Parser) AtMost(n int) *Parser {
+	return AtMost(n, p)
+}
+
+// Repeat 应用当前解析器至少min次，至多max次
+func (p *Parser) Repeat(min int, max int) *Parser {
+	return Repeat(min, max, p)
 }
 
 // Opt 将当前解析器变为可选，并提供默认解析结果
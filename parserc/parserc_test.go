@@ -66,6 +66,118 @@ func TestStr(t *testing.T) {
 	verifySuccess(t, Str("abc"), "abc", "abc")
 }
 
+func TestChFold(t *testing.T) {
+	verifyFailed(t, ChFold('a'), "")
+	verifySuccess(t, ChFold('a'), "a", 'a')
+	verifySuccess(t, ChFold('a'), "A", 'a')
+	verifySuccess(t, ChFoldRaw('a'), "A", 'A')
+	verifyFailed(t, ChFold('a'), "b")
+	verifySuccess(t, ChFold('k'), "\u212A", 'k')
+	verifySuccess(t, ChFold('σ'), "ς", 'σ')
+}
+
+func TestStrFold(t *testing.T) {
+	verifyFailed(t, StrFold("select"), "")
+	verifyFailed(t, StrFold("select"), "sel")
+	verifySuccess(t, StrFold("select"), "select", "select")
+	verifySuccess(t, StrFold("select"), "SELECT", "select")
+	verifySuccess(t, StrFoldRaw("select"), "SeLeCt", "SeLeCt")
+	verifySuccess(t, StrFold("Content-Type"), "content-type", "Content-Type")
+
+	_, err := StrFold("select").ParseToEnd("update")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected select")
+}
+
+func TestKeyword(t *testing.T) {
+	verifySuccess(t, Keyword("true"), "true", "true")
+	verifyFailed(t, Keyword("true"), "")
+	verifyFailed(t, Keyword("true"), "tru")
+	verifyFailed(t, Keyword("true"), "TRUE")
+	verifySuccess(t, Keyword("true").Skip(Ch(' ')), "true ", "true")
+	verifyFailed(t, Keyword("true").Skip(Any().Many()), "trueish")
+	verifyFailed(t, Keyword("true").Skip(Any().Many()), "true_1")
+	verifySuccess(t, KeywordFold("select"), "SeLeCt", "select")
+	verifyFailed(t, KeywordFold("select"), "selects")
+}
+
+func TestIdentifier(t *testing.T) {
+	letter := Range('a', 'z').Or(Range('A', 'Z')).Or(Ch('_'))
+	rest := letter.Or(Range('0', '9'))
+	verifySuccess(t, Identifier(letter, rest, "if", "else"), "a", "a")
+	verifySuccess(t, Identifier(letter, rest, "if", "else"), "_x12", "_x12")
+	verifySuccess(t, Identifier(letter, rest, "if", "else"), "iffy", "iffy")
+	verifySuccess(t, Identifier(letter, rest, "if", "else"), "IF", "IF")
+	verifyFailed(t, Identifier(letter, rest, "if", "else"), "")
+	verifyFailed(t, Identifier(letter, rest, "if", "else"), "1a")
+	verifyFailed(t, Identifier(letter, rest, "if", "else"), "else")
+	verifyFailed(t, IdentifierFold(letter, rest, "if", "else"), "ELSE")
+
+	_, err := Identifier(letter, rest, "if").ParseToEnd("if")
+	assert.EqualError(t, err, "parse error at row 1, col 1: reserved keyword if")
+}
+
+func TestRegex(t *testing.T) {
+	verifySuccess(t, Regex(`[0-9]+`), "123", "123")
+	verifySuccess(t, Regex(`[0-9]+`).And(Ch('a')), "12a", Pair{"12", 'a'})
+	verifySuccess(t, Regex(`你.`).And(Ch('!')), "你好!", Pair{"你好", '!'})
+	verifySuccess(t, Ch('a').And(Regex(`bc|b`)), "abc", Pair{'a', "bc"})
+	verifyFailed(t, Ch('a').And(Regex(`b|bc`)), "abc")
+	verifySuccess(t, Regex(`a\nb`).And(Ch('c')), "a\nbc", Pair{"a\nb", 'c'})
+	verifyFailed(t, Regex(`[0-9]+`), "a1")
+	verifyFailed(t, Regex(`[0-9]+`), "")
+	r, err := Regex(`.`).Many().ParseBytes([]byte{0x89, 'P'})
+	assert.Nil(t, err)
+	assert.Equal(t, []any{"\x89", "P"}, r)
+	_, err = Regex(`\d`).ParseToEnd("x")
+	assert.Equal(t, "parse error at row 1, col 1: expected /\\d/", err.Error())
+	assert.Panics(t, func() {
+		Regex(`(`)
+	})
+}
+
+func TestParsePrefix(t *testing.T) {
+	input := CreateInput("ab\ncd")
+	r, err := Str("ab\nc").Parse(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "ab\nc", r.Result)
+	assert.Equal(t, 2, r.Remain.Row())
+	assert.Equal(t, 2, r.Remain.Col())
+	assert.Equal(t, "ab\nc", input.TextTo(r.Remain))
+	assert.Equal(t, 'd', r.Remain.Current())
+}
+
+func TestLiterals(t *testing.T) {
+	verifySuccess(t, Literals("apple", "banana", "cat"), "apple", "apple")
+	verifySuccess(t, Literals("apple", "banana", "cat"), "banana", "banana")
+	verifySuccess(t, Literals("apple", "banana", "cat"), "cat", "cat")
+	verifyFailed(t, Literals("apple", "banana", "cat"), "doctor")
+	verifyFailed(t, Literals("apple", "banana", "cat"), "")
+	verifyFailed(t, Literals("apple", "banana", "cat"), "app")
+	verifySuccess(t, Literals("a", "ab"), "ab", "ab")
+	verifySuccess(t, Literals("ab", "a"), "ab", "ab")
+	verifySuccess(t, Literals("a", "abc").And(Ch('b')), "ab", Pair{"a", 'b'})
+	verifySuccess(t, Literals("<", "<=", "<<", "<<=").Many(), "<<=<<<=", []any{"<<=", "<<", "<="})
+	verifySuccess(t, Literals("a", ""), "", "")
+	verifySuccess(t, Literals("a", "").And(Ch('b')), "b", Pair{"", 'b'})
+	verifySuccess(t, Literals("a", ""), "a", "a")
+	verifySuccess(t, OneOf(Literals("a", ""), Ch('x')).And(Ch('b')), "b", Pair{"", 'b'})
+
+	words := []string{"w0", "w1", "w2", "w3", "w4", "w5", "w6", "w7", "w8", "w9"}
+	_, err := Literals(words...).ParseToEnd("x")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected one of w0, w1, w2, w3, w4, w5, w6, w7, ... (2 more)")
+}
+
+func TestLiteralsMap(t *testing.T) {
+	table := map[string]any{"+": 1, "++": 2, "+=": 3}
+	verifySuccess(t, LiteralsMap(table), "+", 1)
+	verifySuccess(t, LiteralsMap(table), "++", 2)
+	verifySuccess(t, LiteralsMap(table), "+=", 3)
+	verifyFailed(t, LiteralsMap(table), "-")
+
+	_, err := LiteralsMap(table).ParseToEnd("-")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected one of +, ++, +=")
+}
+
 func TestMap(t *testing.T) {
 	verifySuccess(t, Str("abc").Map(func(r any) any {
 		return r.(string) + " hello"
@@ -127,6 +239,58 @@ func TestMany1(t *testing.T) {
 	verifySuccess(t, Ch('a').Many1(), "aaa", []any{'a', 'a', 'a'})
 }
 
+func TestTimes(t *testing.T) {
+	hex := OneOf(Range('0', '9'), Range('a', 'f'), Range('A', 'F'))
+	verifySuccess(t, Skip(Str("\\u")).And(hex.Times(4)), "\\u00e9", []any{'0', '0', 'e', '9'})
+	verifyFailed(t, Skip(Str("\\u")).And(hex.Times(4)), "\\u00e")
+	verifyFailed(t, Skip(Str("\\u")).And(hex.Times(4)), "\\u00e9f")
+	verifySuccess(t, Ch('a').Times(0), "", []any{})
+
+	_, err := Ch('a').Times(3).ParseToEnd("aab")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected exactly 3 repetitions, found 2")
+	_, err = Ch('a').AtLeast(3).ParseToEnd("aab")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected at least 3 repetitions, found 2")
+	assert.PanicsWithValue(t, "parserc.Times: negative repetition count -1", func() {
+		Ch('a').Times(-1)
+	})
+	assert.PanicsWithValue(t, "parserc.AtLeast: negative repetition count -2", func() {
+		Ch('a').AtLeast(-2)
+	})
+	assert.PanicsWithValue(t, "parserc.AtMost: negative repetition count -1", func() {
+		Ch('a').AtMost(-1)
+	})
+}
+
+func TestAtLeast(t *testing.T) {
+	verifyFailed(t, Ch('a').AtLeast(2), "")
+	verifyFailed(t, Ch('a').AtLeast(2), "a")
+	verifySuccess(t, Ch('a').AtLeast(2), "aa", []any{'a', 'a'})
+	verifySuccess(t, Ch('a').AtLeast(2), "aaaa", []any{'a', 'a', 'a', 'a'})
+}
+
+func TestAtMost(t *testing.T) {
+	verifySuccess(t, Ch('a').AtMost(2), "", []any{})
+	verifySuccess(t, Ch('a').AtMost(2), "a", []any{'a'})
+	verifySuccess(t, Ch('a').AtMost(2), "aa", []any{'a', 'a'})
+	verifyFailed(t, Ch('a').AtMost(2), "aaa")
+}
+
+func TestRepeat(t *testing.T) {
+	octet := Range('0', '9').Repeat(1, 3)
+	ip := Separate(Ch('.'), octet)
+	verifySuccess(t, ip, "1.22.255.0", []any{[]any{'1'}, []any{'2', '2'}, []any{'2', '5', '5'}, []any{'0'}})
+	verifyFailed(t, ip, "1..2")
+	verifyFailed(t, ip, "1.2345")
+	_, err := Ch('a').Repeat(2, 3).ParseToEnd("ab")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected at least 2 repetitions, found 1")
+	assert.PanicsWithValue(t, "parserc.Repeat: min 3 greater than max 2", func() {
+		Ch('a').Repeat(3, 2)
+	})
+	assert.PanicsWithValue(t, "parserc.Repeat: negative repetition count -1", func() {
+		Ch('a').Repeat(-1, 2)
+	})
+}
+
 func TestOptional(t *testing.T) {
 	verifySuccess(t, Ch('a').Opt('x'), "", 'x')
 	verifySuccess(t, Ch('a').Opt('x'), "a", 'a')
@@ -145,6 +309,41 @@ func TestSeparatedBy(t *testing.T) {
 	verifyFailed(t, Separate(Ch(','), Any()), "")
 }
 
+func TestSepBy(t *testing.T) {
+	verifySuccess(t, SepBy(Ch(','), Any()), "", []any{})
+	verifySuccess(t, SepBy(Ch(','), Any()), "a", []any{'a'})
+	verifySuccess(t, SepBy(Ch(','), Any()), "a,b,c", []any{'a', 'b', 'c'})
+	verifyFailed(t, SepBy(Ch(','), Range('a', 'z')), "a,b,")
+	verifySuccess(t, SepByKeep(Ch(','), Any()), "a,b,c", []any{'a', ',', 'b', ',', 'c'})
+	verifySuccess(t, SepBy(Ch(','), Range('a', 'z')).And(Str(",")), "a,b,", Pair{[]any{'a', 'b'}, ","})
+}
+
+func TestSepBy1(t *testing.T) {
+	verifyFailed(t, SepBy1(Ch(','), Any()), "")
+	verifySuccess(t, SepBy1(Ch(','), Any()), "a", []any{'a'})
+	verifySuccess(t, SepBy1(Ch(','), Any()), "a,b,c", []any{'a', 'b', 'c'})
+	verifySuccess(t, SepBy1Keep(Ch(','), Any()), "a,b", []any{'a', ',', 'b'})
+}
+
+func TestSepEndBy(t *testing.T) {
+	verifySuccess(t, SepEndBy(Ch(','), Range('a', 'z')), "", []any{})
+	verifyFailed(t, SepEndBy(Ch(','), Range('a', 'z')), ",")
+	verifySuccess(t, SepEndBy(Ch(','), Range('a', 'z')), "a", []any{'a'})
+	verifySuccess(t, SepEndBy(Ch(','), Range('a', 'z')), "a,", []any{'a'})
+	verifySuccess(t, SepEndBy(Ch(','), Range('a', 'z')), "a,b,c", []any{'a', 'b', 'c'})
+	verifySuccess(t, SepEndBy(Ch(','), Range('a', 'z')), "a,b,c,", []any{'a', 'b', 'c'})
+	verifyFailed(t, SepEndBy(Ch(','), Range('a', 'z')), "a,,")
+	verifySuccess(t, SepEndByKeep(Ch(','), Range('a', 'z')), "a,b,", []any{'a', ',', 'b', ','})
+}
+
+func TestEndBy(t *testing.T) {
+	verifySuccess(t, EndBy(Ch(';'), Range('a', 'z')), "", []any{})
+	verifySuccess(t, EndBy(Ch(';'), Range('a', 'z')), "a;b;", []any{'a', 'b'})
+	verifyFailed(t, EndBy(Ch(';'), Range('a', 'z')), "a;b")
+	verifySuccess(t, EndBy(Ch(';'), Range('a', 'z')).And(Ch('b')), "a;b", Pair{[]any{'a'}, 'b'})
+	verifySuccess(t, EndByKeep(Ch(';'), Range('a', 'z')), "a;b;", []any{'a', ';', 'b', ';'})
+}
+
 func TestSurroundedBy(t *testing.T) {
 	verifySuccess(t, Ch('a').Surround(Ch('b')), "bab", 'a')
 	verifyFailed(t, Ch('a').Surround(Ch('b')), "bax")
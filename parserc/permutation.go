@@ -0,0 +1,101 @@
+package parserc
+
+import "fmt"
+
+type permutationField struct {
+	name         string
+	parser       *Parser
+	required     bool
+	defaultValue any
+}
+
+// PermutationBuilder 排列解析器构造器
+type PermutationBuilder struct {
+	fields    []permutationField
+	delimiter *Parser
+}
+
+// Permutation 创建排列解析器构造器，各字段可以以任意顺序出现且至多出现一次
+func Permutation() *PermutationBuilder {
+	return &PermutationBuilder{}
+}
+
+// Required 添加必选字段
+func (b *PermutationBuilder) Required(name string, p *Parser) *PermutationBuilder {
+	b.fields = append(b.fields, permutationField{name, p, true, nil})
+	return b
+}
+
+// Optional 添加可选字段，字段缺失时解析结果为defaultValue
+func (b *PermutationBuilder) Optional(name string, p *Parser, defaultValue any) *PermutationBuilder {
+	b.fields = append(b.fields, permutationField{name, p, false, defaultValue})
+	return b
+}
+
+// Separate 设置字段之间的分隔符
+func (b *PermutationBuilder) Separate(delimiter *Parser) *PermutationBuilder {
+	b.delimiter = delimiter
+	return b
+}
+
+// Parser 生成排列解析器，解析结果按字段声明顺序排列
+func (b *PermutationBuilder) Parser() *Parser {
+	fields := append([]permutationField{}, b.fields...)
+	delimiter := b.delimiter
+	names := make([]any, 0)
+	children := make([]*Parser, 0)
+	for _, f := range fields {
+		names = append(names, f.name)
+		children = append(children, f.parser)
+	}
+	return &Parser{kind: "Permutation", args: names, children: children, parse: func(input Input) (ParseResult, error) {
+		rs := make([]any, len(fields))
+		matched := make([]bool, len(fields))
+		i := input
+		for count := 0; ; count++ {
+			next := i
+			if count > 0 && delimiter != nil {
+				d, err := delimiter.run(i)
+				if err != nil {
+					break
+				}
+				next = d.Remain
+			}
+			index := -1
+			for k, f := range fields {
+				if matched[k] {
+					continue
+				}
+				r, err := f.parser.run(next)
+				if err == nil {
+					index = k
+					rs[k] = r.Result
+					matched[k] = true
+					i = r.Remain
+					break
+				}
+			}
+			if index < 0 {
+				for k, f := range fields {
+					if !matched[k] {
+						continue
+					}
+					if _, err := f.parser.run(next); err == nil {
+						return emptyParseResult, parseError(next, fmt.Sprintf("duplicate field %s", f.name))
+					}
+				}
+				break
+			}
+		}
+		for k, f := range fields {
+			if matched[k] {
+				continue
+			}
+			if f.required {
+				return emptyParseResult, parseError(i, fmt.Sprintf("missing required field %s", f.name))
+			}
+			rs[k] = f.defaultValue
+		}
+		return ParseResult{rs, i}, nil
+	}}
+}
@@ -0,0 +1,46 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPermutation(t *testing.T) {
+	p := Permutation().
+		Required("a", Ch('a')).
+		Required("b", Ch('b')).
+		Optional("c", Ch('c'), 'x').
+		Parser()
+	verifySuccess(t, p, "abc", []any{'a', 'b', 'c'})
+	verifySuccess(t, p, "cba", []any{'a', 'b', 'c'})
+	verifySuccess(t, p, "bca", []any{'a', 'b', 'c'})
+	verifySuccess(t, p, "ba", []any{'a', 'b', 'x'})
+	verifyFailed(t, p, "")
+	verifyFailed(t, p, "ac")
+	verifyFailed(t, p, "abca")
+
+	_, err := p.ParseToEnd("ca")
+	assert.EqualError(t, err, "parse error at row 1, col 3: missing required field b")
+	_, err = p.ParseToEnd("aba")
+	assert.EqualError(t, err, "parse error at row 1, col 3: duplicate field a")
+}
+
+func TestPermutationSeparate(t *testing.T) {
+	attr := func(name string) *Parser {
+		return Skip(Str(name + "=")).And(Range('0', '9').Many1())
+	}
+	p := Permutation().
+		Required("width", attr("width")).
+		Optional("height", attr("height"), []any{'0'}).
+		Separate(Ch(' ')).
+		Parser()
+	verifySuccess(t, p, "width=1 height=2", []any{[]any{'1'}, []any{'2'}})
+	verifySuccess(t, p, "height=2 width=1", []any{[]any{'1'}, []any{'2'}})
+	verifySuccess(t, p, "width=1", []any{[]any{'1'}, []any{'0'}})
+	verifyFailed(t, p, "width=1height=2")
+	verifyFailed(t, p, "width=1 ")
+	verifySuccess(t, p.And(Ch(' ')), "width=1 ", Pair{[]any{[]any{'1'}, []any{'0'}}, ' '})
+
+	_, err := p.ParseToEnd("width=1 width=2")
+	assert.EqualError(t, err, "parse error at row 1, col 9: duplicate field width")
+}
@@ -0,0 +1,234 @@
+package parserc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuleStats 命名规则的统计信息
+type RuleStats struct {
+	Name       string        // 规则名称
+	Calls      int           // 调用次数
+	Successes  int           // 成功次数
+	Failures   int           // 失败次数
+	Backtracks int           // 回溯次数，即同一次解析中在已尝试过的位置上再次调用该规则的次数
+	Time       time.Duration // 累计耗时，包含子规则的耗时
+}
+
+// Profile 性能分析与覆盖率统计结果，可在多次解析之间累积，也可以通过Merge合并
+type Profile struct {
+	mu       sync.Mutex
+	rules    map[*Parser]*RuleStats
+	branches map[*Parser][]int
+	choices  []*Parser
+}
+
+// NewProfile 创建空的统计结果
+func NewProfile() *Profile {
+	return &Profile{rules: make(map[*Parser]*RuleStats), branches: make(map[*Parser][]int)}
+}
+
+// register 登记p可达的所有命名规则与选择分支，使从未执行的规则与分支也出现在报告中
+func (prof *Profile) register(p *Parser) {
+	visited := make(map[*Parser]bool)
+	var visit func(p *Parser)
+	visit = func(p *Parser) {
+		if visited[p] {
+			return
+		}
+		visited[p] = true
+		if p.name != "" {
+			prof.rule(p)
+		}
+		switch p.kind {
+		case "Or", "OneOf", "Opt":
+			if p.origin != nil {
+				prof.choice(p.origin)
+			} else {
+				prof.choice(p)
+			}
+		}
+		for _, c := range p.children {
+			visit(c)
+		}
+	}
+	visit(p)
+}
+
+func (prof *Profile) rule(p *Parser) *RuleStats {
+	s, exist := prof.rules[p]
+	if !exist {
+		s = &RuleStats{Name: ruleName(p)}
+		prof.rules[p] = s
+	}
+	return s
+}
+
+func (prof *Profile) choice(p *Parser) []int {
+	counts, exist := prof.branches[p]
+	if !exist {
+		n := len(p.children)
+		if p.kind == "Opt" {
+			n = 2
+		}
+		counts = make([]int, n)
+		prof.branches[p] = counts
+		prof.choices = append(prof.choices, p)
+	}
+	return counts
+}
+
+// Merge 将other中的统计结果合并到当前结果中
+func (prof *Profile) Merge(other *Profile) {
+	if prof == other {
+		return
+	}
+	// 先在other的锁内复制其统计结果，再锁定当前结果，两者同时互相合并时不会死锁
+	other.mu.Lock()
+	rules := make(map[*Parser]RuleStats, len(other.rules))
+	for p, s := range other.rules {
+		rules[p] = *s
+	}
+	choices := append([]*Parser{}, other.choices...)
+	branches := make(map[*Parser][]int, len(other.branches))
+	for p, counts := range other.branches {
+		branches[p] = append([]int{}, counts...)
+	}
+	other.mu.Unlock()
+
+	prof.mu.Lock()
+	defer prof.mu.Unlock()
+	for p, s := range rules {
+		t := prof.rule(p)
+		t.Calls += s.Calls
+		t.Successes += s.Successes
+		t.Failures += s.Failures
+		t.Backtracks += s.Backtracks
+		t.Time += s.Time
+	}
+	for _, p := range choices {
+		counts := prof.choice(p)
+		for k, n := range branches[p] {
+			counts[k] += n
+		}
+	}
+}
+
+// Rules 获取所有命名规则的统计信息，按累计耗时从高到低排列
+func (prof *Profile) Rules() []RuleStats {
+	prof.mu.Lock()
+	defer prof.mu.Unlock()
+	rules := make([]RuleStats, 0, len(prof.rules))
+	for _, s := range prof.rules {
+		rules = append(rules, *s)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Time != rules[j].Time {
+			return rules[i].Time > rules[j].Time
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+// Report 以表格形式输出各命名规则的统计信息
+func (prof *Profile) Report() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-20s %8s %8s %8s %10s %12s\n", "rule", "calls", "success", "failure", "backtrack", "time"))
+	for _, s := range prof.Rules() {
+		sb.WriteString(fmt.Sprintf("%-20s %8d %8d %8d %10d %12s\n", s.Name, s.Calls, s.Successes, s.Failures, s.Backtracks, s.Time))
+	}
+	return sb.String()
+}
+
+// Coverage 输出每个Or、OneOf分支以及Opt路径的执行次数，从未执行的分支以"never"标记
+func (prof *Profile) Coverage() string {
+	prof.mu.Lock()
+	defer prof.mu.Unlock()
+	var sb strings.Builder
+	covered, total := 0, 0
+	for _, p := range prof.choices {
+		counts := prof.branches[p]
+		taken := 0
+		for _, n := range counts {
+			if n > 0 {
+				taken++
+			}
+		}
+		covered += taken
+		total += len(counts)
+		sb.WriteString(fmt.Sprintf("%s: %d/%d\n", p.String(), taken, len(counts)))
+		for k, n := range counts {
+			var branch string
+			switch {
+			case p.kind != "Opt":
+				branch = fmt.Sprintf("#%d %s", k, p.children[k].describe(false, make(map[*Parser]bool)))
+			case k == 0:
+				branch = "matched"
+			default:
+				branch = "default"
+			}
+			if n == 0 {
+				sb.WriteString(fmt.Sprintf("\t%s: never\n", branch))
+			} else {
+				sb.WriteString(fmt.Sprintf("\t%s: %d\n", branch, n))
+			}
+		}
+	}
+	if total > 0 {
+		sb.WriteString(fmt.Sprintf("branch coverage: %d/%d (%.1f%%)\n", covered, total, float64(covered)*100/float64(total)))
+	}
+	return sb.String()
+}
+
+type profiler struct {
+	result  *Profile
+	stack   []time.Time
+	visited map[*Parser]map[int]bool
+}
+
+func (t *profiler) enter(p *Parser, input Input) {
+	s := t.result.rule(p)
+	s.Calls++
+	positions, exist := t.visited[p]
+	if !exist {
+		positions = make(map[int]bool)
+		t.visited[p] = positions
+	}
+	if positions[input.index] {
+		s.Backtracks++
+	}
+	positions[input.index] = true
+	t.stack = append(t.stack, time.Now())
+}
+
+func (t *profiler) exit(p *Parser, input Input, r ParseResult, err error) {
+	start := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	s := t.result.rule(p)
+	s.Time += time.Since(start)
+	if err != nil {
+		s.Failures++
+	} else {
+		s.Successes++
+	}
+}
+
+func (t *profiler) branch(p *Parser, index int) {
+	t.result.choice(p)[index]++
+}
+
+// Profile 返回一个解析器，解析时将当前解析器以及所有命名子规则的调用统计、选择分支的覆盖情况累积到prof中
+func (p *Parser) Profile(prof *Profile) *Parser {
+	prof.mu.Lock()
+	prof.register(p)
+	prof.mu.Unlock()
+	return withHook(p, "Profile", func() ruleHook {
+		return &profiler{result: NewProfile(), visited: make(map[*Parser]map[int]bool)}
+	}, func(h ruleHook) {
+		prof.Merge(h.(*profiler).result)
+	})
+}
@@ -0,0 +1,100 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func findRule(rules []RuleStats, name string) RuleStats {
+	for _, s := range rules {
+		if s.Name == name {
+			return s
+		}
+	}
+	return RuleStats{}
+}
+
+func TestProfile(t *testing.T) {
+	prof := NewProfile()
+	p := traceGrammar().Profile(prof)
+	verifySuccess(t, p, "(12)", []any{'1', '2'})
+	verifySuccess(t, p, "3", []any{'3'})
+	rules := prof.Rules()
+	assert.Equal(t, 2, len(rules))
+	expr := findRule(rules, "expr")
+	assert.Equal(t, 3, expr.Calls)
+	assert.Equal(t, 3, expr.Successes)
+	assert.Equal(t, 0, expr.Failures)
+	number := findRule(rules, "number")
+	assert.Equal(t, 3, number.Calls)
+	assert.Equal(t, 2, number.Successes)
+	assert.Equal(t, 1, number.Failures)
+	report := prof.Report()
+	assert.True(t, strings.HasPrefix(report, "rule "))
+	assert.Contains(t, report, "number")
+}
+
+func TestProfileBacktracks(t *testing.T) {
+	prof := NewProfile()
+	a := Ch('a').Named("a")
+	p := Or(a.And(Ch('b')), a.And(Ch('c'))).Profile(prof)
+	verifySuccess(t, p, "ac", Pair{'a', 'c'})
+	s := findRule(prof.Rules(), "a")
+	assert.Equal(t, 2, s.Calls)
+	assert.Equal(t, 1, s.Backtracks)
+}
+
+func TestProfileCoverage(t *testing.T) {
+	prof := NewProfile()
+	p := OneOf(Ch('a'), Ch('b'), Ch('c')).And(Ch('!').Opt(nil)).Profile(prof)
+	verifySuccess(t, p, "a", Pair{'a', nil})
+	verifySuccess(t, p, "b!", Pair{'b', '!'})
+	assert.Equal(t, `OneOf(Ch('a'), Ch('b'), Ch('c')): 2/3
+	#0 Ch('a'): 1
+	#1 Ch('b'): 1
+	#2 Ch('c'): never
+Opt(Ch('!'), nil): 2/2
+	matched: 1
+	default: 1
+branch coverage: 4/5 (80.0%)
+`, prof.Coverage())
+}
+
+func TestProfileMerge(t *testing.T) {
+	p := Or(Ch('a'), Ch('b')).Named("ab")
+	prof1, prof2 := NewProfile(), NewProfile()
+	verifySuccess(t, p.Profile(prof1), "a", 'a')
+	verifySuccess(t, p.Profile(prof2), "b", 'b')
+	verifyFailed(t, p.Profile(prof2), "c")
+	prof1.Merge(prof2)
+	s := findRule(prof1.Rules(), "ab")
+	assert.Equal(t, 3, s.Calls)
+	assert.Equal(t, 2, s.Successes)
+	assert.Equal(t, 1, s.Failures)
+	assert.Contains(t, prof1.Coverage(), "branch coverage: 2/2")
+}
+
+func TestProfileMergeConcurrent(t *testing.T) {
+	p := Or(Ch('a'), Ch('b')).Named("ab")
+	prof1, prof2 := NewProfile(), NewProfile()
+	verifySuccess(t, p.Profile(prof1), "a", 'a')
+	verifySuccess(t, p.Profile(prof2), "b", 'b')
+	var wg sync.WaitGroup
+	// 互相合并会使计数成倍增长，次数不宜过多
+	for k := 0; k < 10; k++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			prof1.Merge(prof2)
+		}()
+		go func() {
+			defer wg.Done()
+			prof2.Merge(prof1)
+		}()
+	}
+	wg.Wait()
+	assert.Contains(t, prof1.Coverage(), "branch coverage: 2/2")
+	assert.Contains(t, prof2.Coverage(), "branch coverage: 2/2")
+}
@@ -0,0 +1,313 @@
+package parserc
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	rrCharWidth = 8.0  // 单个字符的估计宽度
+	rrBoxHeight = 22.0 // 终结符与非终结符方框的高度
+	rrArc       = 10.0 // 连接线转弯半径
+	rrGap       = 10.0 // 元素之间的间距
+	rrMargin    = 20.0 // 图的外边距
+)
+
+// rrItem 铁路图元素，元素从左侧基线进入、从右侧基线离开
+type rrItem interface {
+	width() float64
+	up() float64   // 基线以上的高度
+	down() float64 // 基线以下的高度
+	render(sb *strings.Builder, x float64, y float64)
+}
+
+func rrLine(sb *strings.Builder, x1 float64, y1 float64, x2 float64, y2 float64) {
+	sb.WriteString(fmt.Sprintf(`<path d="M%g %gL%g %g"/>`, x1, y1, x2, y2))
+	sb.WriteString("\n")
+}
+
+type rrBox struct {
+	text    string
+	rounded bool
+}
+
+func (b rrBox) width() float64 {
+	return float64(utf8.RuneCountInString(b.text))*rrCharWidth + 2*rrGap
+}
+
+func (b rrBox) up() float64 {
+	return rrBoxHeight / 2
+}
+
+func (b rrBox) down() float64 {
+	return rrBoxHeight / 2
+}
+
+func (b rrBox) render(sb *strings.Builder, x float64, y float64) {
+	radius := 0.0
+	class := "nonterminal"
+	if b.rounded {
+		radius = rrBoxHeight / 2
+		class = "terminal"
+	}
+	sb.WriteString(fmt.Sprintf(`<g class="%s"><rect x="%g" y="%g" width="%g" height="%g" rx="%g"/><text x="%g" y="%g">%s</text></g>`,
+		class, x, y-rrBoxHeight/2, b.width(), rrBoxHeight, radius, x+b.width()/2, y+4, html.EscapeString(b.text)))
+	sb.WriteString("\n")
+}
+
+type rrSkip struct{}
+
+func (rrSkip) width() float64 {
+	return 0
+}
+
+func (rrSkip) up() float64 {
+	return 0
+}
+
+func (rrSkip) down() float64 {
+	return 0
+}
+
+func (rrSkip) render(*strings.Builder, float64, float64) {}
+
+type rrSequence []rrItem
+
+func (s rrSequence) width() float64 {
+	w := 0.0
+	for k, item := range s {
+		if k > 0 {
+			w += rrGap
+		}
+		w += item.width()
+	}
+	return w
+}
+
+func (s rrSequence) up() float64 {
+	h := 0.0
+	for _, item := range s {
+		h = maxFloat(h, item.up())
+	}
+	return h
+}
+
+func (s rrSequence) down() float64 {
+	h := 0.0
+	for _, item := range s {
+		h = maxFloat(h, item.down())
+	}
+	return h
+}
+
+func (s rrSequence) render(sb *strings.Builder, x float64, y float64) {
+	for k, item := range s {
+		if k > 0 {
+			rrLine(sb, x, y, x+rrGap, y)
+			x += rrGap
+		}
+		item.render(sb, x, y)
+		x += item.width()
+	}
+}
+
+// rrChoice 第一个分支位于基线上，其余分支依次排列在下方
+type rrChoice []rrItem
+
+func (c rrChoice) innerWidth() float64 {
+	w := 0.0
+	for _, item := range c {
+		w = maxFloat(w, item.width())
+	}
+	return w
+}
+
+func (c rrChoice) width() float64 {
+	return c.innerWidth() + 4*rrArc
+}
+
+func (c rrChoice) up() float64 {
+	return c[0].up()
+}
+
+func (c rrChoice) offsets() []float64 {
+	offsets := []float64{0}
+	y := c[0].down()
+	for _, item := range c[1:] {
+		y += rrGap + maxFloat(item.up(), rrArc)
+		offsets = append(offsets, y)
+		y += item.down()
+	}
+	return offsets
+}
+
+func (c rrChoice) down() float64 {
+	offsets := c.offsets()
+	return offsets[len(offsets)-1] + c[len(c)-1].down()
+}
+
+func (c rrChoice) render(sb *strings.Builder, x float64, y float64) {
+	inner := c.innerWidth()
+	right := x + c.width()
+	for k, item := range c {
+		iy := y + c.offsets()[k]
+		ix := x + 2*rrArc
+		if k == 0 {
+			rrLine(sb, x, y, ix, y)
+		} else {
+			sb.WriteString(fmt.Sprintf(`<path d="M%g %gQ%g %g %g %gL%g %gQ%g %g %g %g"/>`,
+				x, y, x+rrArc, y, x+rrArc, y+rrArc, x+rrArc, iy-rrArc, x+rrArc, iy, ix, iy))
+			sb.WriteString("\n")
+		}
+		item.render(sb, ix, iy)
+		rrLine(sb, ix+item.width(), iy, ix+inner, iy)
+		if k == 0 {
+			rrLine(sb, ix+inner, y, right, y)
+		} else {
+			sb.WriteString(fmt.Sprintf(`<path d="M%g %gQ%g %g %g %gL%g %gQ%g %g %g %g"/>`,
+				ix+inner, iy, right-rrArc, iy, right-rrArc, iy-rrArc, right-rrArc, y+rrArc, right-rrArc, y, right, y))
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// rrLoop 元素位于基线上，回路位于元素下方，label为回路上的次数说明
+type rrLoop struct {
+	item  rrItem
+	label string
+}
+
+func (l rrLoop) width() float64 {
+	return l.item.width() + 4*rrArc
+}
+
+func (l rrLoop) up() float64 {
+	return l.item.up()
+}
+
+func (l rrLoop) down() float64 {
+	d := l.item.down() + rrGap + rrArc
+	if l.label != "" {
+		d += 14
+	}
+	return d
+}
+
+func (l rrLoop) render(sb *strings.Builder, x float64, y float64) {
+	ix := x + 2*rrArc
+	right := ix + l.item.width()
+	rrLine(sb, x, y, ix, y)
+	l.item.render(sb, ix, y)
+	rrLine(sb, right, y, right+2*rrArc, y)
+	ly := y + l.item.down() + rrGap
+	sb.WriteString(fmt.Sprintf(`<path d="M%g %gQ%g %g %g %gL%g %gQ%g %g %g %gL%g %gQ%g %g %g %gL%g %gQ%g %g %g %g"/>`,
+		right, y, right+rrArc, y, right+rrArc, y+rrArc,
+		right+rrArc, ly-rrArc, right+rrArc, ly, right, ly,
+		ix, ly, x+rrArc, ly, x+rrArc, ly-rrArc,
+		x+rrArc, y+rrArc, x+rrArc, y, ix, y))
+	sb.WriteString("\n")
+	if l.label != "" {
+		sb.WriteString(fmt.Sprintf(`<text class="label" x="%g" y="%g">%s</text>`, (ix+right)/2, ly+14, html.EscapeString(l.label)))
+		sb.WriteString("\n")
+	}
+}
+
+func (x *expr) railroad() rrItem {
+	switch x.kind {
+	case exprTerminal:
+		text := strconv.Quote(x.text)
+		if x.fold {
+			text += "i"
+		}
+		return rrBox{text, true}
+	case exprClass:
+		return rrBox{x.text, true}
+	case exprSpecial:
+		return rrBox{"? " + x.text + " ?", false}
+	case exprRef:
+		return rrBox{x.text, false}
+	case exprSeq:
+		items := make(rrSequence, 0)
+		for _, item := range x.items {
+			items = append(items, item.railroad())
+		}
+		return items
+	case exprChoice:
+		items := make(rrChoice, 0)
+		for _, item := range x.items {
+			items = append(items, item.railroad())
+		}
+		return items
+	case exprRepeat:
+		item := x.items[0].railroad()
+		switch {
+		case x.min == 0 && x.max == 1:
+			return rrChoice{rrSkip{}, item}
+		case x.min == 0 && x.max < 0:
+			return rrChoice{rrSkip{}, rrLoop{item, ""}}
+		case x.min == 1 && x.max < 0:
+			return rrLoop{item, ""}
+		}
+		label := fmt.Sprintf("%d..%d", x.min, x.max)
+		if x.max < 0 {
+			label = fmt.Sprintf("%d..", x.min)
+		}
+		if x.min == 0 {
+			return rrChoice{rrSkip{}, rrLoop{item, label}}
+		}
+		return rrLoop{item, label}
+	}
+	return rrSkip{}
+}
+
+const railroadStyle = `<style>
+path { fill: none; stroke: #333; stroke-width: 1.5; }
+rect { fill: #fff; stroke: #333; stroke-width: 1.5; }
+.terminal rect { fill: #e8f4e8; }
+.nonterminal rect { fill: #e8eef8; }
+text { font: 13px monospace; text-anchor: middle; }
+text.title { font-weight: bold; text-anchor: start; }
+text.label { font-size: 11px; }
+</style>`
+
+// Railroad 将当前解析器及其引用的规则导出为SVG格式的铁路图，每条规则一幅图
+func (p *Parser) Railroad() string {
+	var body strings.Builder
+	width, y := 0.0, rrMargin
+	for _, r := range exportRules(p) {
+		item := r.expr.railroad()
+		body.WriteString(fmt.Sprintf(`<text class="title" x="%g" y="%g">%s</text>`, rrMargin, y+12, html.EscapeString(r.name)))
+		body.WriteString("\n")
+		y += 20 + item.up() + rrGap
+		x := rrMargin
+		body.WriteString(fmt.Sprintf(`<path d="M%g %gL%g %gM%g %gL%g %g"/>`, x, y-rrGap, x, y+rrGap, x+4, y-rrGap, x+4, y+rrGap))
+		body.WriteString("\n")
+		rrLine(&body, x, y, x+rrGap*2, y)
+		item.render(&body, x+rrGap*2, y)
+		end := x + rrGap*2 + item.width()
+		rrLine(&body, end, y, end+rrGap*2, y)
+		end += rrGap * 2
+		body.WriteString(fmt.Sprintf(`<path d="M%g %gL%g %gM%g %gL%g %g"/>`, end, y-rrGap, end, y+rrGap, end-4, y-rrGap, end-4, y+rrGap))
+		body.WriteString("\n")
+		width = maxFloat(width, end+rrMargin)
+		y += item.down() + rrGap + rrMargin
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`, width, y, width, y))
+	sb.WriteString("\n")
+	sb.WriteString(railroadStyle)
+	sb.WriteString("\n")
+	sb.WriteString(body.String())
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+func maxFloat(a float64, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,42 @@
+package parserc
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRailroad(t *testing.T) {
+	expr := NewParser().Named("expr")
+	number := Range('0', '9').Many1().Named("number")
+	fact := OneOf(number, Skip(Ch('(')).And(expr).Skip(Ch(')'))).Named("fact")
+	expr.Set(SepBy1(Chs('+', '-'), fact.Times(2).Opt(nil)))
+
+	svg := expr.Railroad()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if err != nil {
+			break
+		}
+	}
+	for _, s := range []string{
+		`<text class="title" x="20" y="32">expr</text>`,
+		`<text class="title"`,
+		`>fact</text>`,
+		`>number</text>`,
+		`>&#34;(&#34;</text>`,
+		`>[+\-]</text>`,
+		`>2..2</text>`,
+	} {
+		assert.Contains(t, svg, s)
+	}
+	assert.Equal(t, 3, strings.Count(svg, `class="title"`))
+}
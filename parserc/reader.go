@@ -0,0 +1,143 @@
+package parserc
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"unicode/utf8"
+)
+
+// chunkSize 从io.Reader读取输入时每个数据块包含的字符数
+const chunkSize = 4096
+
+// runeReader 数据块共享的读取状态
+type runeReader struct {
+	r     *bufio.Reader
+	err   error    // 读取过程中遇到的错误，到达末尾时为io.EOF
+	size  int      // 已读取的字节数
+	limit int      // 最大输入长度（字节），为0时不限制
+	enc   Encoding // 根据BOM检测到的编码
+	row   int      // 下一个字符的行号，用于报告无效编码的位置
+	col   int      // 下一个字符的列号
+}
+
+func newRuneReader(r io.Reader) *runeReader {
+	reader := &runeReader{r: bufio.NewReader(r), row: 1, col: 1}
+	b, _ := reader.r.Peek(3)
+	enc, bom := DetectEncoding(b)
+	_, _ = reader.r.Discard(bom)
+	reader.enc, reader.size = enc, bom
+	return reader
+}
+
+// readRune 读取一个字符，编码无效时返回EncodingError
+func (r *runeReader) readRune() (rune, int, error) {
+	var c rune
+	var size int
+	var msg string
+	if r.enc == EncodingUTF8 {
+		var err error
+		c, size, err = r.r.ReadRune()
+		if err != nil {
+			return 0, 0, err
+		}
+		if c == utf8.RuneError && size == 1 {
+			msg = "invalid UTF-8 sequence"
+		}
+	} else {
+		b, err := r.r.Peek(4)
+		if len(b) == 0 {
+			return 0, 0, err
+		}
+		c, size, msg = decodeRune(r.enc, b)
+		_, _ = r.r.Discard(size)
+	}
+	if msg != "" {
+		return 0, 0, &EncodingError{"", r.size, r.row, r.col, msg}
+	}
+	if c == '\n' {
+		r.row, r.col = r.row+1, 1
+	} else {
+		r.col++
+	}
+	return c, size, nil
+}
+
+// chunk 从io.Reader读取的一段输入，各数据块按顺序链接，后续数据块在首次访问时读取。
+// Input只引用其所在的数据块，不再被任何回溯点引用的数据块可被垃圾回收
+type chunk struct {
+	runes  []rune
+	next   *chunk
+	reader *runeReader
+}
+
+func (r *runeReader) read() *chunk {
+	c := &chunk{reader: r}
+	for r.err == nil && len(c.runes) < chunkSize {
+		ch, size, err := r.readRune()
+		if err != nil {
+			r.err = err
+			break
+		}
+		r.size += size
+		if r.limit > 0 && r.size > r.limit {
+			panic(abortPanic{&InputSizeError{r.size, r.limit}})
+		}
+		c.runes = append(c.runes, ch)
+	}
+	return c
+}
+
+// load 获取下一个数据块，没有更多输入时返回nil
+func (c *chunk) load() *chunk {
+	if c.next == nil && c.reader.err == nil {
+		next := c.reader.read()
+		if len(next.runes) == 0 {
+			return nil
+		}
+		c.next = next
+	}
+	return c.next
+}
+
+// CreateReaderInput 创建从r读取的输入流，输入在解析过程中按需读取。
+// 输入开头的BOM会被去除，UTF-16输入会被转码，遇到无效的编码时输入流在该位置结束
+func CreateReaderInput(r io.Reader) Input {
+	return createReaderInput(newRuneReader(r))
+}
+
+func createReaderInput(r *runeReader) Input {
+	return Input{"", 0, 1, 1, nil, r.read(), 0, false, nil, nil}
+}
+
+// readError 返回读取过程中遇到的错误，正常到达末尾时返回nil
+func (r *runeReader) readError() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// ParseReader 与ParseToEnd相同，但从r读取输入，输入包含无效的编码时返回EncodingError
+func (p Parser) ParseReader(r io.Reader) (any, error) {
+	reader := newRuneReader(r)
+	result, err := p.parseToEnd(createReaderInput(reader))
+	if readErr := reader.readError(); readErr != nil {
+		return nil, readErr
+	}
+	return result, err
+}
+
+// ParseReaderContext 与ParseToEndContext相同，但从r读取输入，WithMaxInputSize限制读取的字节数
+func (p Parser) ParseReaderContext(ctx context.Context, r io.Reader, options ...ParseOption) (any, error) {
+	l := newParseLimits(ctx, options)
+	reader := newRuneReader(r)
+	reader.limit = l.maxInputSize
+	result, err := l.parse(&p, func() Input {
+		return createReaderInput(reader)
+	})
+	if readErr := reader.readError(); readErr != nil {
+		return nil, readErr
+	}
+	return result, err
+}
@@ -0,0 +1,69 @@
+package parserc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseReader(t *testing.T) {
+	r, err := Str("你好").And(Ch('!')).ParseReader(iotest.OneByteReader(strings.NewReader("你好!")))
+	assert.Nil(t, err)
+	assert.Equal(t, Pair{"你好", '!'}, r)
+	r, err = Ch('a').Many().ParseReader(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{}, r)
+	_, err = Ch('a').ParseReader(strings.NewReader("ab"))
+	assert.Equal(t, "parse error at row 1, col 2: end of input not reached", err.Error())
+	_, err = Ch('a').ParseReader(strings.NewReader("b"))
+	assert.Equal(t, "parse error at row 1, col 1: expected a", err.Error())
+}
+
+func TestParseReaderAcrossChunks(t *testing.T) {
+	s := strings.Repeat("ab\n", chunkSize)
+	line := Str("ab").Skip(Ch('\n'))
+	r, err := line.Many().ParseReader(strings.NewReader(s))
+	assert.Nil(t, err)
+	assert.Equal(t, chunkSize, len(r.([]any)))
+
+	p := Or(Str("ab\nab").Many().And(Ch('x')), line.Many().Map(func(r any) any {
+		return len(r.([]any))
+	}))
+	r, err = p.ParseReader(strings.NewReader(s))
+	assert.Nil(t, err)
+	assert.Equal(t, chunkSize, r)
+	_, err = line.Many().ParseReader(strings.NewReader(s + "ac"))
+	assert.Equal(t, "parse error at row 4097, col 1: end of input not reached", err.Error())
+}
+
+func TestParseReaderTrace(t *testing.T) {
+	var sb strings.Builder
+	s := strings.Repeat("a", chunkSize+10)
+	_, err := Ch('a').Many().Named("as").Trace(&sb).ParseReader(strings.NewReader(s))
+	assert.Nil(t, err)
+	assert.Contains(t, sb.String(), "consumed \""+s+"\"")
+}
+
+func TestParseReaderError(t *testing.T) {
+	readErr := errors.New("broken")
+	_, err := Ch('a').Many().ParseReader(iotest.DataErrReader(iotest.TimeoutReader(strings.NewReader(strings.Repeat("a", 10000)))))
+	assert.Equal(t, iotest.ErrTimeout, err)
+	_, err = Ch('a').ParseReader(iotest.ErrReader(readErr))
+	assert.Equal(t, readErr, err)
+}
+
+func TestParseReaderContext(t *testing.T) {
+	r, err := nestedGrammar().ParseReaderContext(context.Background(), strings.NewReader("((x))"), WithMaxDepth(3))
+	assert.Nil(t, err)
+	assert.Equal(t, 'x', r)
+	_, err = nestedGrammar().ParseReaderContext(context.Background(), strings.NewReader("(((x)))"), WithMaxDepth(3))
+	var depthErr *DepthLimitError
+	assert.True(t, errors.As(err, &depthErr))
+	_, err = Ch('a').Many().ParseReaderContext(context.Background(), strings.NewReader(strings.Repeat("a", chunkSize*3)), WithMaxInputSize(chunkSize*2))
+	var sizeErr *InputSizeError
+	assert.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, chunkSize*2, sizeErr.Limit)
+}
@@ -0,0 +1,132 @@
+package parserc
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// source 输入的来源
+type source struct {
+	name   string
+	parent *Input // 被包含时包含指令所在的位置，顶层输入为nil
+}
+
+// chain 返回包含链的描述，每一层占一行
+func (s *source) chain() string {
+	var sb strings.Builder
+	for p := s.parent; p != nil; p = p.src.parent {
+		name := p.Name()
+		if name == "" {
+			name = "<input>"
+		}
+		sb.WriteString(fmt.Sprintf("\n\tincluded from %s at row %d, col %d", name, p.Row(), p.Col()))
+		if p.src == nil {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// CreateNamedInput 创建带有名称的输入流，名称通常为文件路径，会出现在错误信息中。与CreateInput一样不检查s的编码
+func CreateNamedInput(name string, s string) Input {
+	input := CreateInput(s)
+	input.src = &source{name: name}
+	return input
+}
+
+// Name 获取输入流的名称，没有名称时返回空字符串
+func (p Input) Name() string {
+	if p.src == nil {
+		return ""
+	}
+	return p.src.name
+}
+
+// ParseFile 读取指定文件并解析直到末尾，错误信息中包含文件路径。
+// 文件开头的BOM会被去除，UTF-16文件会被转码，文件包含无效的编码时返回EncodingError
+func (p Parser) ParseFile(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decodeBytes(path, data, false)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseToEnd(CreateNamedInput(path, s))
+}
+
+// Include 先以path解析出被包含文件的路径，再读取该文件并以body解析其全部内容，解析结果为body的解析结果，
+// 之后从包含指令之后继续解析。相对路径相对于当前输入流名称所在的目录。
+// path匹配后，文件无法读取或body解析失败时不再回溯，直接以附带完整包含链的错误结束整个解析
+func Include(path *Parser, body *Parser) *Parser {
+	return include("Include", func(base string, name string) string {
+		if base == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(filepath.Dir(base), name)
+	}, os.ReadFile, path, body)
+}
+
+// IncludeFS 与Include相同，但从fsys中读取被包含的文件
+func IncludeFS(fsys fs.FS, path *Parser, body *Parser) *Parser {
+	return include("IncludeFS", func(base string, name string) string {
+		if base == "" || pathpkg.IsAbs(name) {
+			return name
+		}
+		return pathpkg.Join(pathpkg.Dir(base), name)
+	}, func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}, path, body)
+}
+
+func include(kind string, resolve func(string, string) string, read func(string) ([]byte, error), path *Parser, body *Parser) *Parser {
+	return &Parser{kind: kind, children: []*Parser{path, body}, parse: func(input Input) (ParseResult, error) {
+		r, err := path.run(input)
+		if err != nil {
+			return emptyParseResult, err
+		}
+		name, ok := r.Result.(string)
+		if !ok {
+			return emptyParseResult, parseError(input, fmt.Sprintf("include path must be a string, found %v", r.Result))
+		}
+		name = resolve(input.Name(), name)
+		for s := input.src; s != nil; s = s.parent.src {
+			if s.name == name {
+				panic(abortPanic{parseError(input, fmt.Sprintf("recursive include of %s", name))})
+			}
+			if s.parent == nil {
+				break
+			}
+		}
+		data, err := read(name)
+		if err != nil {
+			panic(abortPanic{parseError(input, fmt.Sprintf("cannot include %s: %v", name, err))})
+		}
+		text, err := decodeBytes(name, data, false)
+		if err != nil {
+			panic(abortPanic{err})
+		}
+		site := input
+		nested := CreateInput(text)
+		nested.src = &source{name: name, parent: &site}
+		nested.ctx = input.ctx
+		if input.ctx != nil && input.ctx.partial {
+			ctx := *input.ctx
+			ctx.partial = false
+			nested.ctx = &ctx
+		}
+		nr, err := body.run(nested)
+		if err != nil {
+			panic(abortPanic{err})
+		}
+		if !nr.Remain.End() {
+			panic(abortPanic{parseError(nr.Remain, "end of input not reached")})
+		}
+		return ParseResult{nr.Result, r.Remain}, nil
+	}}
+}
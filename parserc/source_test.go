@@ -0,0 +1,118 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func includeGrammar(fsys fstest.MapFS) *Parser {
+	line := NewParser()
+	name := Skip(Str("include ")).And(Not('\n').Many1().Map(func(r any) any {
+		var s []rune
+		for _, c := range r.([]any) {
+			s = append(s, c.(rune))
+		}
+		return string(s)
+	}))
+	lines := line.Skip(Ch('\n')).Many()
+	line.Set(OneOf(IncludeFS(fsys, name, lines), Range('a', 'z').Many1().Map(func(r any) any {
+		return len(r.([]any))
+	})))
+	return lines
+}
+
+func TestNamedInput(t *testing.T) {
+	input := CreateNamedInput("a.conf", "x")
+	assert.Equal(t, "a.conf", input.Name())
+	assert.Equal(t, "a.conf", input.Next().Name())
+	assert.Equal(t, "", CreateInput("x").Name())
+	_, err := Ch('a').ParseInput(CreateNamedInput("a.conf", "\nb"))
+	assert.Equal(t, "parse error in a.conf at row 1, col 1: expected a", err.Error())
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("ab\nac"), 0644))
+	_, err := Str("ab\nab").ParseFile(path)
+	assert.Equal(t, "parse error in "+path+" at row 1, col 1: expected ab\nab", err.Error())
+	r, err := Str("ab\nac").ParseFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "ab\nac", r)
+	_, err = Str("ab").ParseFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err)
+}
+
+func TestIncludeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.conf":         {Data: []byte("ab\ninclude sub/a.conf\nc\n")},
+		"sub/a.conf":        {Data: []byte("xyz\ninclude b.conf\n")},
+		"sub/b.conf":        {Data: []byte("q\n")},
+		"bad.conf":          {Data: []byte("ab\ninclude sub/bad.conf\n")},
+		"sub/bad.conf":      {Data: []byte("x\ninclude c.conf\n")},
+		"sub/c.conf":        {Data: []byte("ok\n1\n")},
+		"loop.conf":         {Data: []byte("include loop.conf\n")},
+		"missing.conf":      {Data: []byte("include nothing.conf\n")},
+		"trailing.conf":     {Data: []byte("include sub/trailing.conf\n")},
+		"sub/trailing.conf": {Data: []byte("a\nB")},
+	}
+	p := includeGrammar(fsys)
+	parse := func(name string) (any, error) {
+		data, _ := fsys.ReadFile(name)
+		return p.ParseInput(CreateNamedInput(name, string(data)))
+	}
+	r, err := parse("main.conf")
+	assert.Nil(t, err)
+	assert.Equal(t, []any{2, []any{3, []any{1}}, 1}, r)
+
+	_, err = parse("bad.conf")
+	assert.Equal(t, "parse error in sub/c.conf at row 2, col 1: end of input not reached"+
+		"\n\tincluded from sub/bad.conf at row 2, col 1"+
+		"\n\tincluded from bad.conf at row 2, col 1", err.Error())
+
+	_, err = parse("loop.conf")
+	assert.Equal(t, "parse error in loop.conf at row 1, col 1: recursive include of loop.conf", err.Error())
+	_, err = parse("missing.conf")
+	assert.Equal(t, "parse error in missing.conf at row 1, col 1: cannot include nothing.conf: open nothing.conf: file does not exist", err.Error())
+
+	_, err = parse("trailing.conf")
+	assert.Equal(t, "parse error in sub/trailing.conf at row 2, col 1: end of input not reached"+
+		"\n\tincluded from trailing.conf at row 1, col 1", err.Error())
+}
+
+func TestIncludeErrors(t *testing.T) {
+	fsys := fstest.MapFS{"a": {Data: []byte("include a")}, "b": {Data: []byte("!")}}
+	name := Skip(Str("include ")).And(Range('a', 'z').Map(func(r any) any {
+		return string(r.(rune))
+	}))
+	p := NewParser()
+	p.Set(IncludeFS(fsys, name, p))
+	_, err := p.ParseInput(CreateNamedInput("a", "include a"))
+	assert.Equal(t, "parse error in a at row 1, col 1: recursive include of a", err.Error())
+	_, err = p.ParseToEnd("include c")
+	assert.Equal(t, "parse error at row 1, col 1: cannot include c: open c: file does not exist", err.Error())
+	_, err = IncludeFS(fsys, name, Ch('?')).ParseToEnd("include b")
+	assert.Equal(t, "parse error in b at row 1, col 1: expected ?\n\tincluded from <input> at row 1, col 1", err.Error())
+	_, err = IncludeFS(fsys, Str("b"), Ch('!')).ParseToEnd("b")
+	assert.Nil(t, err)
+	_, err = IncludeFS(fsys, Ch('b'), Ch('!')).ParseToEnd("b")
+	assert.Equal(t, "parse error at row 1, col 1: include path must be a string, found 98", err.Error())
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "main.txt"), []byte("<inc.txt>"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "inc.txt"), []byte("hello"), 0644))
+	file := Skip(Ch('<')).And(Not('>').Many1().Map(func(r any) any {
+		var s []rune
+		for _, c := range r.([]any) {
+			s = append(s, c.(rune))
+		}
+		return string(s)
+	})).Skip(Ch('>'))
+	r, err := Include(file, Str("hello")).ParseFile(filepath.Join(dir, "main.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", r)
+}
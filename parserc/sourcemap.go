@@ -0,0 +1,242 @@
+package parserc
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ColumnMode 列号的计数方式
+type ColumnMode int
+
+const (
+	ColumnRune     ColumnMode = iota // 按Unicode码点计数，与Input的Col一致
+	ColumnUTF8                       // 按UTF-8字节计数
+	ColumnUTF16                      // 按UTF-16码元计数，与LSP一致
+	ColumnGrapheme                   // 按字素簇计数，即用户感知的字符
+)
+
+const columnModes = 4
+
+// sourceMark 行内的特殊字符，即非ASCII字符与需要展开的制表符。
+// 两个特殊字符之间的字符在各种计数方式下都恰好占1个单位
+type sourceMark struct {
+	at     int              // 字符在行内的字节偏移
+	bytes  int              // 字符之后在行内的字节数
+	runes  int              // 字符之后在行内的码点数
+	col    [columnModes]int // 字符之后在行内各计数方式下的列数，从0开始
+	extend bool             // 字符是否与前一个字符属于同一个字素簇
+}
+
+type sourceLine struct {
+	start     int // 行首的字节偏移
+	runeStart int // 行首的码点序号
+	marks     []sourceMark
+}
+
+// SourceMap 输入文本的行表，一次构建后以O(log n)的复杂度在字节偏移、码点序号与行列号之间转换。
+// 行号与列号从1开始，与Input的Row、Col一致
+type SourceMap struct {
+	src      string
+	tabWidth int
+	lines    []sourceLine
+}
+
+// NewSourceMap 为s构建行表，tabWidth大于1时制表符将列号推进到下一个tabWidth的整数倍之后，否则制表符占1列
+func NewSourceMap(s string, tabWidth int) *SourceMap {
+	m := &SourceMap{src: s, tabWidth: tabWidth}
+	line := sourceLine{}
+	var col [columnModes]int
+	var prev rune
+	regional := 0
+	for i, runes := 0, 0; i < len(s); runes++ {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r >= utf8.RuneSelf || r == '\t' && tabWidth > 1 {
+			extend := graphemeExtend(prev, r, regional)
+			inc := [columnModes]int{1, size, 1, 1}
+			if r > 0xffff {
+				inc[ColumnUTF16] = 2
+			}
+			if extend {
+				inc[ColumnGrapheme] = 0
+			}
+			for k := range col {
+				if r == '\t' {
+					col[k] = (col[k]/tabWidth + 1) * tabWidth
+				} else {
+					col[k] += inc[k]
+				}
+			}
+			at := i - line.start
+			line.marks = append(line.marks, sourceMark{at, at + size, runes - line.runeStart + 1, col, extend})
+		} else {
+			for k := range col {
+				col[k]++
+			}
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+		i += size
+		if r == '\n' {
+			m.lines = append(m.lines, line)
+			line = sourceLine{start: i, runeStart: runes + 1}
+			col = [columnModes]int{}
+			prev = 0
+		}
+	}
+	m.lines = append(m.lines, line)
+	return m
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// graphemeExtend 判断r是否与前一个字符prev属于同一个字素簇，regional为prev及之前连续的区域指示符个数。
+// 这是Unicode字素簇划分规则的简化版本，覆盖组合字符、零宽连接符序列、emoji修饰符、国旗与韩文字母
+func graphemeExtend(prev rune, r rune, regional int) bool {
+	switch {
+	case prev == 0:
+		return false
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == 0x200d || prev == 0x200d:
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff:
+		return true
+	case isRegionalIndicator(r):
+		return regional%2 == 1
+	case r >= 0x1160 && r <= 0x11ff:
+		return prev >= 0x1100 && prev <= 0x11ff || prev >= 0xac00 && prev <= 0xd7a3
+	}
+	return false
+}
+
+// LineCount 获取行数
+func (m *SourceMap) LineCount() int {
+	return len(m.lines)
+}
+
+// runeStart 将字节偏移限制在输入范围内，并调整到所在字符的起始位置
+func (m *SourceMap) runeStart(offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	if offset >= len(m.src) {
+		return len(m.src)
+	}
+	for k := 0; k < utf8.UTFMax-1 && offset > 0 && !utf8.RuneStart(m.src[offset]); k++ {
+		offset--
+	}
+	return offset
+}
+
+func (m *SourceMap) lineEnd(line int) int {
+	if line+1 < len(m.lines) {
+		return m.lines[line+1].start - 1
+	}
+	return len(m.src)
+}
+
+// locate 查找字节偏移所在的行，以及该行中位于偏移之前的最后一个特殊字符的序号，不存在时为-1
+func (m *SourceMap) locate(offset int) (int, int) {
+	line := sort.Search(len(m.lines), func(k int) bool {
+		return m.lines[k].start > offset
+	}) - 1
+	rel := offset - m.lines[line].start
+	marks := m.lines[line].marks
+	mark := sort.Search(len(marks), func(k int) bool {
+		return marks[k].at >= rel
+	}) - 1
+	return line, mark
+}
+
+// Position 获取字节偏移所在的行号与指定计数方式下的列号，偏移位于字符中间时按该字符的起始位置计算
+func (m *SourceMap) Position(offset int, mode ColumnMode) (int, int) {
+	offset = m.runeStart(offset)
+	line, mark := m.locate(offset)
+	l := m.lines[line]
+	rel := offset - l.start
+	bytes, col := 0, 0
+	if mark >= 0 {
+		bytes, col = l.marks[mark].bytes, l.marks[mark].col[mode]
+	}
+	col += rel - bytes
+	if mode == ColumnGrapheme && mark+1 < len(l.marks) && l.marks[mark+1].at == rel && l.marks[mark+1].extend {
+		col--
+	}
+	return line + 1, col + 1
+}
+
+// Offset 获取指定行号与列号对应的字节偏移。列号位于展开的制表符、代理对或字素簇中间时返回该字符的起始位置，
+// 超出行尾时返回行尾的位置
+func (m *SourceMap) Offset(line int, col int, mode ColumnMode) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(m.lines) {
+		return len(m.src)
+	}
+	l := m.lines[line-1]
+	c := col - 1
+	marks := l.marks
+	mark := sort.Search(len(marks), func(k int) bool {
+		return marks[k].col[mode] > c
+	}) - 1
+	rel := c
+	if mark >= 0 {
+		rel = marks[mark].bytes + c - marks[mark].col[mode]
+	}
+	if rel < 0 {
+		rel = 0
+	}
+	if mark+1 < len(marks) && rel > marks[mark+1].at {
+		rel = marks[mark+1].at
+	}
+	offset := l.start + rel
+	if end := m.lineEnd(line - 1); offset > end {
+		offset = end
+	}
+	return offset
+}
+
+// RuneIndex 获取字节偏移对应的码点序号，即Input中字符的序号
+func (m *SourceMap) RuneIndex(offset int) int {
+	offset = m.runeStart(offset)
+	line, mark := m.locate(offset)
+	l := m.lines[line]
+	rel := offset - l.start
+	if mark < 0 {
+		return l.runeStart + rel
+	}
+	return l.runeStart + l.marks[mark].runes + rel - l.marks[mark].bytes
+}
+
+// ByteOffset 获取码点序号对应的字节偏移
+func (m *SourceMap) ByteOffset(runeIndex int) int {
+	if runeIndex <= 0 {
+		return 0
+	}
+	line := sort.Search(len(m.lines), func(k int) bool {
+		return m.lines[k].runeStart > runeIndex
+	}) - 1
+	l := m.lines[line]
+	r := runeIndex - l.runeStart
+	marks := l.marks
+	mark := sort.Search(len(marks), func(k int) bool {
+		return marks[k].runes > r
+	}) - 1
+	rel := r
+	if mark >= 0 {
+		rel = marks[mark].bytes + r - marks[mark].runes
+	}
+	offset := l.start + rel
+	if end := m.lineEnd(line); offset > end {
+		offset = end
+	}
+	return offset
+}
@@ -0,0 +1,94 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"unicode/utf8"
+)
+
+func verifyPosition(t *testing.T, m *SourceMap, offset int, mode ColumnMode, line int, col int) {
+	l, c := m.Position(offset, mode)
+	assert.Equal(t, [2]int{line, col}, [2]int{l, c}, "offset %d, mode %d", offset, mode)
+}
+
+func TestSourceMapASCII(t *testing.T) {
+	m := NewSourceMap("ab\ncd\n\nef", 0)
+	assert.Equal(t, 4, m.LineCount())
+	verifyPosition(t, m, 0, ColumnRune, 1, 1)
+	verifyPosition(t, m, 2, ColumnRune, 1, 3)
+	verifyPosition(t, m, 3, ColumnUTF16, 2, 1)
+	verifyPosition(t, m, 6, ColumnGrapheme, 3, 1)
+	verifyPosition(t, m, 9, ColumnUTF8, 4, 3)
+	verifyPosition(t, m, 100, ColumnUTF8, 4, 3)
+	assert.Equal(t, 4, m.Offset(2, 2, ColumnRune))
+	assert.Equal(t, 5, m.Offset(2, 100, ColumnRune))
+	assert.Equal(t, 6, m.Offset(3, 1, ColumnRune))
+	assert.Equal(t, 0, m.Offset(0, 1, ColumnRune))
+	assert.Equal(t, 9, m.Offset(9, 1, ColumnRune))
+	assert.Equal(t, 4, m.RuneIndex(4))
+	assert.Equal(t, 4, m.ByteOffset(4))
+}
+
+func TestSourceMapUnicode(t *testing.T) {
+	s := "a你😀b\nxéy"
+	m := NewSourceMap(s, 0)
+	b := 1 + 3
+	verifyPosition(t, m, b, ColumnRune, 1, 3)
+	verifyPosition(t, m, b, ColumnUTF8, 1, 5)
+	verifyPosition(t, m, b, ColumnUTF16, 1, 3)
+	verifyPosition(t, m, b+4, ColumnUTF16, 1, 5)
+	verifyPosition(t, m, b+4, ColumnRune, 1, 4)
+	verifyPosition(t, m, b+2, ColumnRune, 1, 3)
+	assert.Equal(t, b, m.Offset(1, 3, ColumnUTF16))
+	assert.Equal(t, b, m.Offset(1, 4, ColumnUTF16))
+	assert.Equal(t, b+4, m.Offset(1, 5, ColumnUTF16))
+	assert.Equal(t, b+4, m.Offset(1, 9, ColumnUTF8))
+	assert.Equal(t, b, m.Offset(1, 6, ColumnUTF8))
+
+	for k := 0; k <= utf8.RuneCountInString(s); k++ {
+		offset := len(string([]rune(s)[:k]))
+		assert.Equal(t, offset, m.ByteOffset(k))
+		assert.Equal(t, k, m.RuneIndex(offset))
+	}
+	input := CreateInput(s)
+	for !input.End() {
+		offset := m.ByteOffset(input.index)
+		line, col := m.Position(offset, ColumnRune)
+		assert.Equal(t, [2]int{input.Row(), input.Col()}, [2]int{line, col})
+		assert.Equal(t, offset, m.Offset(input.Row(), input.Col(), ColumnRune))
+		input = input.Next()
+	}
+}
+
+func TestSourceMapGrapheme(t *testing.T) {
+	s := "e\u0301x👍🏽y🇨🇳🇯🇵z👨‍👩‍👧!"
+	m := NewSourceMap(s, 0)
+	offsets := []int{0, 3, 4, 12, 13, 21, 29, 30, 48}
+	for k, offset := range offsets {
+		verifyPosition(t, m, offset, ColumnGrapheme, 1, k+1)
+		assert.Equal(t, offset, m.Offset(1, k+1, ColumnGrapheme))
+	}
+	verifyPosition(t, m, 1, ColumnGrapheme, 1, 1)
+	verifyPosition(t, m, 8, ColumnGrapheme, 1, 3)
+	verifyPosition(t, m, 17, ColumnGrapheme, 1, 5)
+	verifyPosition(t, m, 1, ColumnRune, 1, 2)
+}
+
+func TestSourceMapTabs(t *testing.T) {
+	m := NewSourceMap("\tab\tc\n a\tb", 4)
+	verifyPosition(t, m, 0, ColumnRune, 1, 1)
+	verifyPosition(t, m, 1, ColumnRune, 1, 5)
+	verifyPosition(t, m, 3, ColumnRune, 1, 7)
+	verifyPosition(t, m, 4, ColumnUTF16, 1, 9)
+	verifyPosition(t, m, 9, ColumnRune, 2, 5)
+	assert.Equal(t, 0, m.Offset(1, 3, ColumnRune))
+	assert.Equal(t, 1, m.Offset(1, 5, ColumnRune))
+	assert.Equal(t, 3, m.Offset(1, 8, ColumnRune))
+	assert.Equal(t, 4, m.Offset(1, 9, ColumnRune))
+	assert.Equal(t, 8, m.Offset(2, 4, ColumnRune))
+	assert.Equal(t, 4, m.RuneIndex(4))
+	assert.Equal(t, 9, m.ByteOffset(9))
+
+	m = NewSourceMap("\tab", 0)
+	verifyPosition(t, m, 1, ColumnRune, 1, 2)
+}
@@ -0,0 +1,71 @@
+package parserc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func formatArg(arg any) string {
+	switch v := arg.(type) {
+	case rune:
+		return strconv.QuoteRune(v)
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "nil"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// label 返回解析器的组合子类型与参数，例如Str("abc")、Range('0', '9')
+func (p *Parser) label() string {
+	if len(p.args) == 0 {
+		return p.kind
+	}
+	args := make([]string, 0, len(p.args))
+	for _, arg := range p.args {
+		args = append(args, formatArg(arg))
+	}
+	return fmt.Sprintf("%s(%s)", p.kind, strings.Join(args, ", "))
+}
+
+// argsLast 参数位于子解析器之后的组合子，与对应函数的参数顺序保持一致
+var argsLast = map[string]bool{"Opt": true, "Identifier": true, "IdentifierFold": true}
+
+// String 返回解析器的可读描述，例如Seq(Ch('a'), Many(digit))，其中命名的子解析器以名称表示
+func (p *Parser) String() string {
+	return p.describe(true, make(map[*Parser]bool))
+}
+
+func (p *Parser) describe(top bool, expanding map[*Parser]bool) string {
+	if !top && p.name != "" {
+		return p.name
+	}
+	if p.kind == "NewParser" {
+		if len(p.children) == 0 || expanding[p] {
+			return "NewParser"
+		}
+		expanding[p] = true
+		defer delete(expanding, p)
+		return p.children[0].describe(false, expanding)
+	}
+	parts := make([]string, 0, len(p.args)+len(p.children))
+	for _, c := range p.children {
+		parts = append(parts, c.describe(false, expanding))
+	}
+	args := make([]string, 0, len(p.args))
+	for _, arg := range p.args {
+		args = append(args, formatArg(arg))
+	}
+	if argsLast[p.kind] {
+		parts = append(parts, args...)
+	} else {
+		parts = append(args, parts...)
+	}
+	if len(parts) == 0 {
+		return p.kind + "()"
+	}
+	return fmt.Sprintf("%s(%s)", p.kind, strings.Join(parts, ", "))
+}
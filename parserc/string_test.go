@@ -0,0 +1,50 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	digit := Range('0', '9').Named("digit")
+	assert.Equal(t, "Seq(Ch('a'), Many(digit))", Seq(Ch('a'), digit.Many()).String())
+	assert.Equal(t, "Range('0', '9')", digit.String())
+	assert.Equal(t, "Opt(Str(\"abc\"), nil)", Str("abc").Opt(nil).String())
+	assert.Equal(t, "Times(4, Any())", Any().Times(4).String())
+	assert.Equal(t, "SkipFirst(Ch('['), SkipSecond(Ch('a'), Ch(']')))", Skip(Ch('[')).And(Ch('a').Skip(Ch(']'))).String())
+	assert.Equal(t, "NewParser", NewParser().String())
+	assert.Equal(t, "Identifier(Range('a', 'z'), Range('a', 'z'), \"if\", \"else\")", Identifier(Range('a', 'z'), Range('a', 'z'), "if", "else").String())
+}
+
+func TestStringCycle(t *testing.T) {
+	expr := NewParser().Named("expr")
+	expr.Set(Or(Ch('x'), Skip(Ch('(')).And(expr).Skip(Ch(')'))))
+	assert.Equal(t, "Or(Ch('x'), SkipSecond(SkipFirst(Ch('('), expr), Ch(')')))", expr.String())
+
+	list := NewParser()
+	list.Set(Skip(Ch('[')).And(list.Many()).Skip(Ch(']')))
+	assert.Equal(t, "SkipSecond(SkipFirst(Ch('['), Many(NewParser)), Ch(']'))", list.String())
+}
+
+func TestNamed(t *testing.T) {
+	digit := Range('0', '9')
+	number := digit.Many1().Named("number")
+	assert.Equal(t, "Seq(Range('0', '9'), Many1(Range('0', '9')))", Seq(digit, digit.Many1()).String())
+	assert.Equal(t, "Seq(Range('0', '9'), number)", Seq(digit, number).String())
+
+	_, err := number.ParseToEnd("a")
+	assert.EqualError(t, err, "parse error at row 1, col 1: expected number")
+	_, err = digit.Many1().ParseToEnd("a")
+	assert.EqualError(t, err, "parse error at row 1, col 1: unexpected a")
+	group := Seq(Ch('('), number, Ch(')')).Named("group")
+	_, err = group.ParseToEnd("(1x")
+	assert.EqualError(t, err, "parse error at row 1, col 3: expected )")
+	_, err = group.ParseToEnd("(x")
+	assert.EqualError(t, err, "parse error at row 1, col 2: expected number")
+	value := OneOf(number, group).Named("value")
+	_, err = Ch('=').And(value).ParseToEnd("=x")
+	assert.EqualError(t, err, "parse error at row 1, col 2: expected value")
+	_, err = value.ParseToEnd("(1x")
+	assert.EqualError(t, err, "parse error at row 1, col 3: expected )")
+	verifyCompiled(t, Ch('=').And(value), "=1", "=(1)", "=x", "=(x", "=(1x", "")
+}
@@ -0,0 +1,71 @@
+package parserc
+
+import "fmt"
+
+// Token 词法单元，由用户的词法分析器产生，通过CreateTokenInput作为输入流解析
+type Token interface {
+	Kind() any       // 词法单元的类别，用于TokenKind匹配
+	Pos() (int, int) // 词法单元在源文本中的行号与列号，用于报告错误位置
+}
+
+// CreateTokenInput 创建词法单元输入流，输入流中的位置为当前词法单元的位置
+func CreateTokenInput[T Token](tokens []T) Input {
+	ts := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		ts = append(ts, t)
+	}
+	row, col := 1, 1
+	if len(ts) > 0 {
+		row, col = ts[0].Pos()
+	}
+	return Input{"", 0, row, col, nil, nil, 0, false, ts, nil}
+}
+
+// requireChars 字符与字符串解析器只能用于字符输入，词法单元输入流中没有可供匹配的字符
+func requireChars(input Input) error {
+	if input.tokens != nil {
+		return parseError(input, "character parser requires character input")
+	}
+	return nil
+}
+
+// Token 获取当前词法单元，仅适用于词法单元输入流
+func (p Input) Token() Token {
+	return p.tokens[p.index]
+}
+
+// TokenIf 匹配满足pred的词法单元，解析结果为该词法单元，expected用于描述期望的词法单元
+func TokenIf(expected string, pred func(Token) bool) *Parser {
+	return &Parser{kind: "TokenIf", args: []any{expected}, parse: func(input Input) (ParseResult, error) {
+		if input.tokens == nil {
+			return emptyParseResult, parseError(input, "token parser requires token input")
+		}
+		if input.eof() {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected %s, found end of input", expected))
+		}
+		t := input.Token()
+		if !pred(t) {
+			return emptyParseResult, parseError(input, fmt.Sprintf("expected %s, found %v", expected, t))
+		}
+		return ParseResult{t, input.Next()}, nil
+	}}
+}
+
+// TokenKind 匹配指定类别的词法单元，解析结果为该词法单元
+func TokenKind(kind any) *Parser {
+	return describe(TokenIf(fmt.Sprint(kind), func(t Token) bool {
+		return t.Kind() == kind
+	}), "TokenKind", []any{kind})
+}
+
+// AnyToken 匹配任意词法单元
+func AnyToken() *Parser {
+	return describe(TokenIf("token", func(Token) bool {
+		return true
+	}), "AnyToken", nil)
+}
+
+// ParseInput 解析指定输入流直到末尾
+func (p Parser) ParseInput(input Input) (any, error) {
+	return p.parseToEnd(input)
+}
@@ -0,0 +1,119 @@
+package parserc
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type testTokenKind int
+
+const (
+	tokNumber testTokenKind = iota
+	tokPlus
+	tokLParen
+	tokRParen
+)
+
+func (k testTokenKind) String() string {
+	return [...]string{"NUMBER", "PLUS", "LPAREN", "RPAREN"}[k]
+}
+
+type testToken struct {
+	kind testTokenKind
+	text string
+	row  int
+	col  int
+}
+
+func (t testToken) Kind() any {
+	return t.kind
+}
+
+func (t testToken) Pos() (int, int) {
+	return t.row, t.col
+}
+
+func (t testToken) String() string {
+	return fmt.Sprintf("%s(%s)", t.kind, t.text)
+}
+
+func lexTokens(s string) []testToken {
+	tokens := make([]testToken, 0)
+	for k, f := range strings.Fields(s) {
+		kind := tokNumber
+		switch f {
+		case "+":
+			kind = tokPlus
+		case "(":
+			kind = tokLParen
+		case ")":
+			kind = tokRParen
+		}
+		tokens = append(tokens, testToken{kind, f, 1, 2*k + 1})
+	}
+	return tokens
+}
+
+func tokenGrammar() *Parser {
+	expr := NewParser()
+	number := TokenKind(tokNumber).Map(func(t any) any {
+		return t.(testToken).text
+	})
+	term := OneOf(number, Skip(TokenKind(tokLParen)).And(expr).Skip(TokenKind(tokRParen)))
+	expr.Set(SepBy1(TokenKind(tokPlus), term))
+	return expr
+}
+
+func TestTokenInput(t *testing.T) {
+	r, err := tokenGrammar().ParseInput(CreateTokenInput(lexTokens("1 + ( 2 + 3 )")))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{"1", []any{"2", "3"}}, r)
+	_, err = tokenGrammar().ParseInput(CreateTokenInput(lexTokens("1 + + 2")))
+	assert.Equal(t, "parse error at row 1, col 3: end of input not reached", err.Error())
+	_, err = Skip(TokenKind(tokNumber)).And(TokenKind(tokPlus)).ParseInput(CreateTokenInput(lexTokens("1 2")))
+	assert.Equal(t, "parse error at row 1, col 3: expected PLUS, found NUMBER(2)", err.Error())
+	_, err = TokenKind(tokNumber).ParseInput(CreateTokenInput([]testToken{}))
+	assert.Equal(t, "parse error at row 1, col 1: expected NUMBER, found end of input", err.Error())
+}
+
+func TestTokenIf(t *testing.T) {
+	small := TokenIf("small number", func(t Token) bool {
+		return t.Kind() == tokNumber && len(t.(testToken).text) == 1
+	})
+	input := CreateTokenInput(lexTokens("7 42"))
+	_, err := small.Many().ParseInput(input)
+	assert.Equal(t, "parse error at row 1, col 3: end of input not reached", err.Error())
+	_, err = small.And(small).ParseInput(input)
+	assert.Equal(t, "parse error at row 1, col 3: expected small number, found NUMBER(42)", err.Error())
+	r, err := small.And(AnyToken()).ParseInput(input)
+	assert.Nil(t, err)
+	assert.Equal(t, Pair{lexTokens("7")[0], testToken{tokNumber, "42", 1, 3}}, r)
+	_, err = Ch('a').ParseInput(CreateInput("a"))
+	assert.Nil(t, err)
+	_, err = AnyToken().ParseToEnd("a")
+	assert.Equal(t, "parse error at row 1, col 1: token parser requires token input", err.Error())
+	assert.Equal(t, "TokenKind(PLUS)", TokenKind(tokPlus).String())
+}
+
+func TestCharParserOnTokens(t *testing.T) {
+	input := CreateTokenInput(lexTokens("1 + 2"))
+	for _, p := range []*Parser{Any(), Ch('1'), Chs('1', '+'), Not('x'), Range('0', '9'), Str("1"), ChFold('a'),
+		StrFold("ab"), Keyword("if"), Literals("1", "+"), CharClass(false, '0', '9')} {
+		_, err := p.ParseInput(input)
+		assert.EqualError(t, err, "parse error at row 1, col 1: character parser requires character input", p.String())
+	}
+	_, err := Skip(TokenKind(tokNumber)).And(Ch('+')).ParseInput(input)
+	assert.EqualError(t, err, "parse error at row 1, col 3: character parser requires character input")
+	r, err := OneOf(Ch('1'), TokenKind(tokNumber)).ParseInput(CreateTokenInput(lexTokens("1")))
+	assert.Nil(t, err)
+	assert.Equal(t, lexTokens("1")[0], r)
+}
+
+func TestTokenTrace(t *testing.T) {
+	var sb strings.Builder
+	_, err := TokenKind(tokNumber).Many().Named("numbers").Trace(&sb).ParseInput(CreateTokenInput(lexTokens("1 2")))
+	assert.Nil(t, err)
+	assert.Contains(t, sb.String(), `consumed "NUMBER(1) NUMBER(2)"`)
+}
@@ -0,0 +1,126 @@
+package parserc
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func ruleName(p *Parser) string {
+	if p.name != "" {
+		return p.name
+	}
+	return p.label()
+}
+
+type textTracer struct {
+	w     io.Writer
+	depth int
+}
+
+func (t *textTracer) enter(p *Parser, input Input) {
+	fmt.Fprintf(t.w, "%senter %s at row %d, col %d\n", strings.Repeat("  ", t.depth), ruleName(p), input.Row(), input.Col())
+	t.depth++
+}
+
+func (t *textTracer) exit(p *Parser, input Input, r ParseResult, err error) {
+	t.depth--
+	indent := strings.Repeat("  ", t.depth)
+	if err != nil {
+		fmt.Fprintf(t.w, "%sfail %s at row %d, col %d: %v\n", indent, ruleName(p), input.Row(), input.Col(), err)
+		return
+	}
+	fmt.Fprintf(t.w, "%smatch %s at row %d, col %d, consumed %s\n", indent, ruleName(p), input.Row(), input.Col(), strconv.Quote(input.TextTo(r.Remain)))
+}
+
+// Trace 返回一个解析器，解析时将当前解析器以及所有命名子规则的进入、退出记录写入w，
+// 记录包括输入位置、成功与否以及消耗的文本，并按调用深度缩进
+func (p *Parser) Trace(w io.Writer) *Parser {
+	return withHook(p, "Trace", func() ruleHook {
+		return &textTracer{w: w}
+	}, func(ruleHook) {})
+}
+
+type traceNode struct {
+	name     string
+	row      int
+	col      int
+	ok       bool
+	consumed string
+	err      string
+	children []*traceNode
+}
+
+type htmlTracer struct {
+	root  traceNode
+	stack []*traceNode
+}
+
+func (t *htmlTracer) enter(p *Parser, input Input) {
+	parent := &t.root
+	if len(t.stack) > 0 {
+		parent = t.stack[len(t.stack)-1]
+	}
+	n := &traceNode{name: ruleName(p), row: input.Row(), col: input.Col()}
+	parent.children = append(parent.children, n)
+	t.stack = append(t.stack, n)
+}
+
+func (t *htmlTracer) exit(p *Parser, input Input, r ParseResult, err error) {
+	n := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	if err != nil {
+		n.err = err.Error()
+		return
+	}
+	n.ok = true
+	n.consumed = input.TextTo(r.Remain)
+}
+
+const traceStyle = `<style>
+body { font: 13px monospace; }
+details { margin-left: 1.5em; }
+summary, .leaf { margin-left: 1.5em; }
+.ok { color: #1a7f37; }
+.fail { color: #cf222e; }
+.pos, .text { color: #666; }
+</style>`
+
+func (n *traceNode) write(sb *strings.Builder) {
+	var summary string
+	if n.ok {
+		summary = fmt.Sprintf(`<span class="ok">%s</span> <span class="pos">%d:%d</span> <span class="text">%s</span>`,
+			html.EscapeString(n.name), n.row, n.col, html.EscapeString(strconv.Quote(n.consumed)))
+	} else {
+		summary = fmt.Sprintf(`<span class="fail">%s</span> <span class="pos">%d:%d</span> <span class="text">%s</span>`,
+			html.EscapeString(n.name), n.row, n.col, html.EscapeString(n.err))
+	}
+	if len(n.children) == 0 {
+		sb.WriteString(fmt.Sprintf("<div class=\"leaf\">%s</div>\n", summary))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("<details open><summary>%s</summary>\n", summary))
+	for _, c := range n.children {
+		c.write(sb)
+	}
+	sb.WriteString("</details>\n")
+}
+
+// TraceHTML 与Trace相同，但在解析结束后将记录以可折叠的HTML文档写入w，该文档不依赖任何外部资源
+func (p *Parser) TraceHTML(w io.Writer) *Parser {
+	return withHook(p, "TraceHTML", func() ruleHook {
+		return &htmlTracer{}
+	}, func(h ruleHook) {
+		var sb strings.Builder
+		sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>parserc trace</title>\n")
+		sb.WriteString(traceStyle)
+		sb.WriteString("\n</head>\n<body>\n")
+		for _, n := range h.(*htmlTracer).root.children {
+			n.write(&sb)
+		}
+		sb.WriteString("</body>\n</html>\n")
+		_, _ = io.WriteString(w, sb.String())
+	})
+}
@@ -0,0 +1,57 @@
+package parserc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func traceGrammar() *Parser {
+	number := Range('0', '9').Many1().Named("number")
+	expr := NewParser().Named("expr")
+	expr.Set(Or(number, Skip(Ch('(')).And(expr).Skip(Ch(')'))))
+	return expr
+}
+
+func TestTrace(t *testing.T) {
+	var sb strings.Builder
+	verifySuccess(t, traceGrammar().Trace(&sb), "(12)", []any{'1', '2'})
+	assert.Equal(t, `enter expr at row 1, col 1
+  enter number at row 1, col 1
+  fail number at row 1, col 1: parse error at row 1, col 1: expected number
+  enter expr at row 1, col 2
+    enter number at row 1, col 2
+    match number at row 1, col 2, consumed "12"
+  match expr at row 1, col 2, consumed "12"
+match expr at row 1, col 1, consumed "(12)"
+`, sb.String())
+}
+
+func TestTraceUnnamedRoot(t *testing.T) {
+	var sb strings.Builder
+	p := Ch('a').And(Ch('b').Named("b"))
+	verifyFailed(t, p.Trace(&sb), "ac")
+	assert.Equal(t, `enter And at row 1, col 1
+  enter b at row 1, col 2
+  fail b at row 1, col 2: parse error at row 1, col 2: expected b
+fail And at row 1, col 1: parse error at row 1, col 2: expected b
+`, sb.String())
+}
+
+func TestTraceComposes(t *testing.T) {
+	var sb strings.Builder
+	p := Ch('x').And(traceGrammar().Trace(&sb)).And(Ch('y'))
+	verifySuccess(t, p, "x1y", Pair{Pair{'x', []any{'1'}}, 'y'})
+	assert.Equal(t, 4, strings.Count(sb.String(), "\n"))
+}
+
+func TestTraceHTML(t *testing.T) {
+	var sb strings.Builder
+	verifySuccess(t, traceGrammar().TraceHTML(&sb), "(12)", []any{'1', '2'})
+	s := sb.String()
+	assert.True(t, strings.HasPrefix(s, "<!DOCTYPE html>"))
+	assert.Contains(t, s, `<details open><summary><span class="ok">expr</span> <span class="pos">1:1</span> <span class="text">&#34;(12)&#34;</span></summary>`)
+	assert.Contains(t, s, `<div class="leaf"><span class="fail">number</span> <span class="pos">1:1</span> <span class="text">parse error at row 1, col 1: expected number</span></div>`)
+	assert.Equal(t, 2, strings.Count(s, "<details"))
+	assert.NotContains(t, s, "<script")
+}
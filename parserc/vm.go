@@ -0,0 +1,630 @@
+package parserc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// opcode 解析虚拟机的指令类型
+type opcode uint8
+
+const (
+	opChar          opcode = iota // 匹配字符arg，压入该字符
+	opSet                         // 匹配字符集sets[arg]中的字符，压入该字符
+	opAny                         // 匹配任意字符，压入该字符
+	opStr                         // 匹配字符串strs[arg]，压入该字符串
+	opKeyword                     // 匹配关键字keywords[arg]，压入该关键字
+	opFail                        // 以消息strs[arg]失败
+	opNative                      // 执行无法编译的解析器natives[arg]，压入解析结果
+	opChoice                      // 压入回溯点，失败时恢复到当前状态并跳转到arg
+	opCommit                      // 弹出回溯点并跳转到arg
+	opPartialCommit               // 将栈顶回溯点更新为当前状态并跳转到arg，用于循环
+	opJump                        // 跳转到arg
+	opCall                        // 调用位于arg的子规则
+	opRet                         // 从子规则返回
+	opPush                        // 压入常量consts[arg]
+	opPair                        // 将栈顶两个值合并为Pair
+	opOpen                        // 开始收集列表
+	opCollect                     // 将最近一次opOpen之后压入的值收集为[]any
+	opMap                         // 以mappers[arg]转换栈顶的值
+	opDrop                        // 丢弃栈顶的值
+	opNip                         // 丢弃栈顶之下的值
+	opEnd                         // 解析成功
+)
+
+var opcodeNames = [...]string{"char", "set", "any", "str", "keyword", "fail", "native", "choice", "commit",
+	"partialcommit", "jump", "call", "ret", "push", "pair", "open", "collect", "map", "drop", "nip", "end"}
+
+type instruction struct {
+	op  opcode
+	arg int
+}
+
+// charset 编译后的字符集，ASCII字符查表匹配，其余字符按区间匹配
+type charset struct {
+	negate bool
+	ascii  [utf8.RuneSelf]bool
+	ranges []rune // 非ASCII部分的闭区间，两两一组
+}
+
+func newCharset(ranges []rune, negate bool) *charset {
+	s := &charset{negate: negate}
+	for k := 0; k+1 < len(ranges); k += 2 {
+		lo, hi := ranges[k], ranges[k+1]
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		for c := lo; c <= hi && c < utf8.RuneSelf; c++ {
+			s.ascii[c] = true
+		}
+		if hi >= utf8.RuneSelf {
+			if lo < utf8.RuneSelf {
+				lo = utf8.RuneSelf
+			}
+			s.ranges = append(s.ranges, lo, hi)
+		}
+	}
+	return s
+}
+
+func (s *charset) match(c rune) bool {
+	if c >= 0 && c < utf8.RuneSelf {
+		return s.ascii[c] != s.negate
+	}
+	for k := 0; k < len(s.ranges); k += 2 {
+		if s.ranges[k] <= c && c <= s.ranges[k+1] {
+			return !s.negate
+		}
+	}
+	return s.negate
+}
+
+type keywordArg struct {
+	word string
+	fold bool
+}
+
+// program 由解析器编译而成的字节码程序
+type program struct {
+	code     []instruction
+	sets     []*charset
+	strs     []string
+	keywords []keywordArg
+	natives  []*Parser
+	consts   []any
+	mappers  []func(any) any
+	names    []string // 以各条指令为入口的命名规则的名称，不是命名规则的入口时为空字符串
+}
+
+// String 输出反汇编结果，用于调试
+func (prog *program) String() string {
+	var sb strings.Builder
+	for pc, ins := range prog.code {
+		arg := ""
+		switch ins.op {
+		case opChar:
+			arg = fmt.Sprintf("%q", rune(ins.arg))
+		case opStr, opFail:
+			arg = fmt.Sprintf("%q", prog.strs[ins.arg])
+		case opKeyword:
+			arg = fmt.Sprintf("%q", prog.keywords[ins.arg].word)
+		case opNative:
+			arg = prog.natives[ins.arg].String()
+		case opPush:
+			arg = formatArg(prog.consts[ins.arg])
+		case opSet, opMap:
+			arg = fmt.Sprintf("#%d", ins.arg)
+		case opChoice, opCommit, opPartialCommit, opJump, opCall:
+			arg = fmt.Sprintf("%d", ins.arg)
+		}
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%4d  %-13s %s", pc, opcodeNames[ins.op], arg), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// compiler 将解析器图编译为字节码。NewParser、命名的解析器以及被多处引用的复合解析器编译为子规则，
+// 其余解析器内联展开
+type compiler struct {
+	prog   *program
+	refs   map[*Parser]int
+	rules  map[*Parser]int
+	queue  []*Parser
+	fixups map[int]*Parser
+}
+
+func compileProgram(p *Parser) *program {
+	c := &compiler{prog: &program{}, refs: make(map[*Parser]int), rules: make(map[*Parser]int), fixups: make(map[int]*Parser)}
+	c.count(p, make(map[*Parser]bool))
+	c.call(p)
+	c.emit(opEnd, 0)
+	for len(c.queue) > 0 {
+		r := c.queue[0]
+		c.queue = c.queue[1:]
+		c.rules[r] = len(c.prog.code)
+		c.body(r)
+		c.emit(opRet, 0)
+	}
+	for pc, r := range c.fixups {
+		c.prog.code[pc].arg = c.rules[r]
+	}
+	c.prog.names = make([]string, len(c.prog.code))
+	for r, entry := range c.rules {
+		c.prog.names[entry] = r.name
+	}
+	return c.prog
+}
+
+// count 统计每个解析器被引用的次数，在循环中展开两次的解析器按两次计算
+func (c *compiler) count(p *Parser, visited map[*Parser]bool) {
+	if visited[p] {
+		return
+	}
+	visited[p] = true
+	for _, child := range p.children {
+		c.refs[child]++
+		c.count(child, visited)
+	}
+	switch p.kind {
+	case "Many1", "Separate", "SepBy", "SepByKeep", "SepBy1", "SepBy1Keep":
+		c.refs[p.children[len(p.children)-1]]++
+	}
+}
+
+func (c *compiler) emit(op opcode, arg int) int {
+	c.prog.code = append(c.prog.code, instruction{op, arg})
+	return len(c.prog.code) - 1
+}
+
+// label 将pc处跳转指令的目标设置为下一条指令
+func (c *compiler) label(pc int) {
+	c.prog.code[pc].arg = len(c.prog.code)
+}
+
+func isLeaf(p *Parser) bool {
+	switch p.kind {
+	case "Ch", "Chs", "Not", "Range", "CharClass", "Any", "Str", "Keyword", "KeywordFold", "Succeed", "Fail":
+		return true
+	}
+	return false
+}
+
+// call 生成调用子规则p的指令
+func (c *compiler) call(p *Parser) {
+	entry, exist := c.rules[p]
+	if !exist {
+		entry = -1
+		c.rules[p] = entry
+		c.queue = append(c.queue, p)
+	}
+	pc := c.emit(opCall, entry)
+	if entry < 0 {
+		c.fixups[pc] = p
+	}
+}
+
+func (c *compiler) compile(p *Parser) {
+	if p.kind == "NewParser" && len(p.children) > 0 || p.name != "" || c.refs[p] > 1 && !isLeaf(p) {
+		c.call(p)
+		return
+	}
+	c.body(p)
+}
+
+func (c *compiler) native(p *Parser) {
+	c.prog.natives = append(c.prog.natives, p)
+	c.emit(opNative, len(c.prog.natives)-1)
+}
+
+func (c *compiler) set(ranges []rune, negate bool) {
+	c.prog.sets = append(c.prog.sets, newCharset(ranges, negate))
+	c.emit(opSet, len(c.prog.sets)-1)
+}
+
+// loop 生成零次或多次执行body的循环，body失败时回溯到该次循环开始之前
+func (c *compiler) loop(body func()) {
+	choice := c.emit(opChoice, 0)
+	start := len(c.prog.code)
+	body()
+	c.emit(opPartialCommit, start)
+	c.label(choice)
+}
+
+// delimited 生成分隔符与元素，keep为false时丢弃分隔符的结果
+func (c *compiler) delimited(delimiter *Parser, p *Parser, keep bool) func() {
+	return func() {
+		c.compile(delimiter)
+		c.compile(p)
+		if !keep {
+			c.emit(opNip, 0)
+		}
+	}
+}
+
+func (c *compiler) body(p *Parser) {
+	children := p.children
+	switch p.kind {
+	case "Ch":
+		c.emit(opChar, int(p.args[0].(rune)))
+	case "Chs":
+		ranges := make([]rune, 0, 2*len(p.args))
+		for _, arg := range p.args {
+			ranges = append(ranges, arg.(rune), arg.(rune))
+		}
+		c.set(ranges, false)
+	case "Not":
+		c.set([]rune{p.args[0].(rune), p.args[0].(rune)}, true)
+	case "Range":
+		c.set([]rune{p.args[0].(rune), p.args[1].(rune)}, false)
+	case "CharClass":
+		ranges := make([]rune, 0, len(p.args)-1)
+		for _, arg := range p.args[1:] {
+			ranges = append(ranges, arg.(rune))
+		}
+		c.set(ranges, p.args[0].(bool))
+	case "Any":
+		c.emit(opAny, 0)
+	case "Str":
+		c.prog.strs = append(c.prog.strs, p.args[0].(string))
+		c.emit(opStr, len(c.prog.strs)-1)
+	case "Fail":
+		c.prog.strs = append(c.prog.strs, p.args[0].(string))
+		c.emit(opFail, len(c.prog.strs)-1)
+	case "Keyword", "KeywordFold":
+		c.prog.keywords = append(c.prog.keywords, keywordArg{p.args[0].(string), p.kind == "KeywordFold"})
+		c.emit(opKeyword, len(c.prog.keywords)-1)
+	case "Succeed":
+		c.prog.consts = append(c.prog.consts, p.args[0])
+		c.emit(opPush, len(c.prog.consts)-1)
+	case "NewParser", "Compiled":
+		if len(children) == 0 {
+			c.native(p)
+			return
+		}
+		c.compile(children[0])
+	case "Map":
+		if p.mapper == nil {
+			c.native(p)
+			return
+		}
+		c.compile(children[0])
+		c.prog.mappers = append(c.prog.mappers, p.mapper)
+		c.emit(opMap, len(c.prog.mappers)-1)
+	case "And":
+		c.compile(children[0])
+		c.compile(children[1])
+		c.emit(opPair, 0)
+	case "SkipFirst":
+		c.compile(children[0])
+		c.compile(children[1])
+		c.emit(opNip, 0)
+	case "SkipSecond":
+		c.compile(children[0])
+		c.compile(children[1])
+		c.emit(opDrop, 0)
+	case "Surround":
+		c.compile(children[1])
+		c.compile(children[0])
+		c.compile(children[1])
+		c.emit(opDrop, 0)
+		c.emit(opNip, 0)
+	case "Seq":
+		c.emit(opOpen, 0)
+		for _, child := range children {
+			c.compile(child)
+		}
+		c.emit(opCollect, 0)
+	case "Or", "OneOf":
+		var commits []int
+		for _, child := range children[:len(children)-1] {
+			choice := c.emit(opChoice, 0)
+			c.compile(child)
+			commits = append(commits, c.emit(opCommit, 0))
+			c.label(choice)
+		}
+		c.compile(children[len(children)-1])
+		for _, pc := range commits {
+			c.label(pc)
+		}
+	case "Opt":
+		choice := c.emit(opChoice, 0)
+		c.compile(children[0])
+		commit := c.emit(opCommit, 0)
+		c.label(choice)
+		c.prog.consts = append(c.prog.consts, p.args[0])
+		c.emit(opPush, len(c.prog.consts)-1)
+		c.label(commit)
+	case "Many":
+		c.emit(opOpen, 0)
+		c.loop(func() { c.compile(children[0]) })
+		c.emit(opCollect, 0)
+	case "Many1":
+		c.emit(opOpen, 0)
+		c.compile(children[0])
+		c.loop(func() { c.compile(children[0]) })
+		c.emit(opCollect, 0)
+	case "Separate":
+		c.emit(opOpen, 0)
+		c.compile(children[1])
+		c.loop(c.delimited(children[0], children[1], false))
+		c.emit(opCollect, 0)
+	case "SepBy", "SepByKeep", "SepBy1", "SepBy1Keep":
+		c.emit(opOpen, 0)
+		empty := -1
+		if p.kind == "SepBy" || p.kind == "SepByKeep" {
+			empty = c.emit(opChoice, 0)
+		}
+		c.compile(children[1])
+		c.loop(c.delimited(children[0], children[1], p.kind == "SepByKeep" || p.kind == "SepBy1Keep"))
+		if empty >= 0 {
+			commit := c.emit(opCommit, 0)
+			c.label(empty)
+			c.label(commit)
+		}
+		c.emit(opCollect, 0)
+	default:
+		c.native(p)
+	}
+}
+
+// vmPosition 字节偏移对应的输入位置，用于按需计算行列号
+type vmPosition struct {
+	offset, index, row, col int
+}
+
+// failure 最近一次失败的位置与原因，与闭包解析器一样，整体失败时报告最近一次失败
+type failure struct {
+	pos int // 失败位置的字节偏移，原生解析器失败时为其错误的位置，无法确定时为-1
+	pc  int
+	err error // 原生解析器返回的错误
+}
+
+type frame struct {
+	pc     int // 回溯点的跳转目标，或子规则的返回地址
+	pos    int // 回溯点的字节偏移，子规则为-1
+	values int
+	lists  int
+	start  int // 子规则开始时的字节偏移
+	entry  int // 子规则的入口
+}
+
+// machine 解析虚拟机的一次执行
+type machine struct {
+	prog   *program
+	input  Input
+	base   vmPosition
+	cache  vmPosition
+	values []any
+	lists  []int
+	stack  []frame
+	last   failure
+}
+
+// at 获取字节偏移pos处的输入，行列号从最近一次计算的位置向后推算
+func (m *machine) at(pos int) Input {
+	if pos < m.cache.offset {
+		m.cache = m.base
+	}
+	s := m.input.str
+	for m.cache.offset < pos {
+		c, size := utf8.DecodeRuneInString(s[m.cache.offset:])
+		m.cache.offset += size
+		m.cache.index++
+		if c == '\n' {
+			m.cache.row++
+			m.cache.col = 1
+		} else {
+			m.cache.col++
+		}
+	}
+	return Input{s, m.cache.index, m.cache.row, m.cache.col, nil, nil, pos, false, nil, m.input.src}
+}
+
+// error 整体失败时的错误，named为在失败位置开始的最外层命名规则的入口，没有时为-1
+func (m *machine) error(named int) error {
+	if named >= 0 {
+		return parseError(m.at(m.last.pos), "expected "+m.prog.names[named])
+	}
+	if m.last.err != nil {
+		return m.last.err
+	}
+	input := m.at(m.last.pos)
+	ins := m.prog.code[m.last.pc]
+	switch ins.op {
+	case opStr:
+		return parseError(input, fmt.Sprintf("expected %s", m.prog.strs[ins.arg]))
+	case opKeyword:
+		return parseError(input, fmt.Sprintf("expected keyword %s", m.prog.keywords[ins.arg].word))
+	case opFail:
+		return parseError(input, m.prog.strs[ins.arg])
+	}
+	if input.End() {
+		return parseError(input, "unexpected end of input")
+	}
+	if ins.op == opChar {
+		return parseError(input, fmt.Sprintf("expected %c", rune(ins.arg)))
+	}
+	return parseError(input, fmt.Sprintf("unexpected %c", input.Current()))
+}
+
+func (m *machine) keyword(pos int, k keywordArg) (int, bool) {
+	s := m.input.str
+	for _, c := range k.word {
+		if pos == len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		if !runeEqual(r, c, k.fold) {
+			return 0, false
+		}
+		pos += size
+	}
+	if pos < len(s) {
+		if r, _ := utf8.DecodeRuneInString(s[pos:]); isIdentRune(r) {
+			return 0, false
+		}
+	}
+	return pos, true
+}
+
+func (m *machine) run() (ParseResult, error) {
+	prog, s := m.prog, m.input.str
+	pos, pc := m.input.offset, 0
+	for {
+		ins := prog.code[pc]
+		ok := true
+		switch ins.op {
+		case opChar, opSet, opAny:
+			if pos == len(s) {
+				ok = false
+				break
+			}
+			c, size := rune(s[pos]), 1
+			if c >= utf8.RuneSelf {
+				c, size = utf8.DecodeRuneInString(s[pos:])
+			}
+			if ins.op == opChar && c != rune(ins.arg) || ins.op == opSet && !prog.sets[ins.arg].match(c) {
+				ok = false
+				break
+			}
+			m.values = append(m.values, c)
+			pos += size
+			pc++
+		case opStr:
+			str := prog.strs[ins.arg]
+			if !strings.HasPrefix(s[pos:], str) {
+				ok = false
+				break
+			}
+			m.values = append(m.values, str)
+			pos += len(str)
+			pc++
+		case opKeyword:
+			k := prog.keywords[ins.arg]
+			end, matched := m.keyword(pos, k)
+			if !matched {
+				ok = false
+				break
+			}
+			m.values = append(m.values, k.word)
+			pos = end
+			pc++
+		case opFail:
+			ok = false
+		case opNative:
+			r, err := prog.natives[ins.arg].run(m.at(pos))
+			if err != nil {
+				m.last = failure{-1, pc, err}
+				if f, ok := err.(*parseFailure); ok && f.src == m.input.src {
+					m.last.pos = f.offset
+				}
+				ok = false
+				break
+			}
+			m.values = append(m.values, r.Result)
+			remain := r.Remain
+			pos = remain.offset
+			m.cache = vmPosition{remain.offset, remain.index, remain.row, remain.col}
+			pc++
+		case opChoice:
+			m.stack = append(m.stack, frame{ins.arg, pos, len(m.values), len(m.lists), 0, 0})
+			pc++
+		case opCommit:
+			m.stack = m.stack[:len(m.stack)-1]
+			pc = ins.arg
+		case opPartialCommit:
+			m.stack[len(m.stack)-1] = frame{m.stack[len(m.stack)-1].pc, pos, len(m.values), len(m.lists), 0, 0}
+			pc = ins.arg
+		case opJump:
+			pc = ins.arg
+		case opCall:
+			m.stack = append(m.stack, frame{pc + 1, -1, 0, 0, pos, ins.arg})
+			pc = ins.arg
+		case opRet:
+			pc = m.stack[len(m.stack)-1].pc
+			m.stack = m.stack[:len(m.stack)-1]
+		case opPush:
+			m.values = append(m.values, prog.consts[ins.arg])
+			pc++
+		case opPair:
+			n := len(m.values)
+			m.values[n-2] = Pair{m.values[n-2], m.values[n-1]}
+			m.values = m.values[:n-1]
+			pc++
+		case opOpen:
+			m.lists = append(m.lists, len(m.values))
+			pc++
+		case opCollect:
+			start := m.lists[len(m.lists)-1]
+			m.lists = m.lists[:len(m.lists)-1]
+			rs := make([]any, len(m.values)-start)
+			copy(rs, m.values[start:])
+			m.values = append(m.values[:start], rs)
+			pc++
+		case opMap:
+			n := len(m.values)
+			m.values[n-1] = prog.mappers[ins.arg](m.values[n-1])
+			pc++
+		case opDrop:
+			m.values = m.values[:len(m.values)-1]
+			pc++
+		case opNip:
+			n := len(m.values)
+			m.values[n-2] = m.values[n-1]
+			m.values = m.values[:n-1]
+			pc++
+		case opEnd:
+			return ParseResult{m.values[0], m.at(pos)}, nil
+		}
+		if ok {
+			continue
+		}
+		if ins.op != opNative {
+			m.last = failure{pos, pc, nil}
+		}
+		// 与闭包解析器一样，命名规则在开始位置失败时报告期望该规则，外层的规则优先
+		named := -1
+		for {
+			if len(m.stack) == 0 {
+				return emptyParseResult, m.error(named)
+			}
+			f := m.stack[len(m.stack)-1]
+			m.stack = m.stack[:len(m.stack)-1]
+			if f.pos < 0 && f.start == m.last.pos && prog.names[f.entry] != "" {
+				named = f.entry
+			}
+			if f.pos >= 0 {
+				pos, pc = f.pos, f.pc
+				m.values = m.values[:f.values]
+				m.lists = m.lists[:f.lists]
+				break
+			}
+		}
+	}
+}
+
+// Compile 将当前解析器编译为字节码，返回在解析虚拟机上执行的等价解析器。
+// 编译在第一次解析时进行，此前应完成所有NewParser的Set。
+// 虚拟机以回溯栈代替闭包调用，减少解析过程中的内存分配；无法编译的组合子（如Peek、Times、Fatal）
+// 以原生指令调用其闭包实现。字节输入、词法单元输入、流式输入、增量解析以及设置了资源限制、
+// Trace或Profile的解析仍由原解析器执行，解析结果与错误信息与原解析器一致
+func (p *Parser) Compile() *Parser {
+	var once sync.Once
+	var prog *program
+	compiled := &Parser{kind: "Compiled", children: []*Parser{p}}
+	compiled.parse = func(input Input) (ParseResult, error) {
+		if input.ctx != nil || input.chunk != nil || input.binary || input.tokens != nil {
+			return p.run(input)
+		}
+		once.Do(func() {
+			prog = compileProgram(p)
+		})
+		m := &machine{prog: prog, input: input}
+		m.base = vmPosition{input.offset, input.index, input.row, input.col}
+		m.cache = m.base
+		return m.run()
+	}
+	return compiled
+}
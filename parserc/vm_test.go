@@ -0,0 +1,131 @@
+package parserc
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// verifyCompiled 验证编译后的解析器与原解析器的解析结果、剩余输入位置与错误信息一致
+func verifyCompiled(t *testing.T, p *Parser, inputs ...string) {
+	compiled := p.Compile()
+	for _, s := range inputs {
+		r1, err1 := p.Parse(CreateInput(s))
+		r2, err2 := compiled.Parse(CreateInput(s))
+		assert.Equal(t, err1, err2, s)
+		if err1 != nil {
+			continue
+		}
+		assert.Equal(t, r1.Result, r2.Result, s)
+		assert.Equal(t, r1.Remain.offset, r2.Remain.offset, s)
+		assert.Equal(t, r1.Remain.index, r2.Remain.index, s)
+		assert.Equal(t, r1.Remain.Row(), r2.Remain.Row(), s)
+		assert.Equal(t, r1.Remain.Col(), r2.Remain.Col(), s)
+	}
+}
+
+func TestCompilePrimitives(t *testing.T) {
+	verifyCompiled(t, Ch('a'), "", "a", "b", "ab")
+	verifyCompiled(t, Ch('中'), "中文", "文")
+	verifyCompiled(t, Chs('a', 'b', 'é'), "", "a", "é", "c")
+	verifyCompiled(t, Not('"'), "", "a", "\"")
+	verifyCompiled(t, Range('0', '9'), "", "5", "a")
+	verifyCompiled(t, Any(), "", "x", "字")
+	verifyCompiled(t, Str("abc"), "", "ab", "abc", "abcd", "abd")
+	verifyCompiled(t, Keyword("if"), "if", "if x", "iff", "i")
+	verifyCompiled(t, KeywordFold("if"), "IF", "If1")
+	verifyCompiled(t, Succeed(1), "", "a")
+	verifyCompiled(t, Fail("boom"), "", "a")
+}
+
+func TestCompileCombinators(t *testing.T) {
+	digit := Range('0', '9')
+	verifyCompiled(t, Ch('a').And(Ch('b')), "ab", "a", "b", "abc")
+	verifyCompiled(t, Seq(Ch('a'), Ch('b'), Ch('c')), "abc", "abd", "")
+	verifyCompiled(t, OneOf(Str("ab"), Str("ac"), Ch('a')), "ab", "ac", "ad", "b")
+	verifyCompiled(t, Ch('a').Or(Ch('b')), "a", "b", "c")
+	verifyCompiled(t, digit.Many(), "", "123", "12a")
+	verifyCompiled(t, digit.Many1(), "", "123", "a")
+	verifyCompiled(t, digit.Opt('x'), "", "1", "a")
+	verifyCompiled(t, Skip(Ch('(')).And(digit).Skip(Ch(')')), "(1)", "(1", "1)")
+	verifyCompiled(t, digit.Surround(Ch(' ').Many()), " 1 ", "1", " a")
+	verifyCompiled(t, SepBy(Ch(','), digit), "", "1", "1,2,3", "1,2,", "a")
+	verifyCompiled(t, SepByKeep(Ch(','), digit), "1,2,3", "1,")
+	verifyCompiled(t, SepBy1(Ch(','), digit), "", "1,2", "a")
+	verifyCompiled(t, SepBy1Keep(Ch(','), digit), "1,2", "")
+	verifyCompiled(t, Separate(Ch(','), digit), "1,2,3", "1,", "")
+	verifyCompiled(t, digit.Map(func(r any) any {
+		return int(r.(rune) - '0')
+	}).Many(), "123", "")
+	verifyCompiled(t, Ch('a').Many1().Many1(), "aaa", "b")
+}
+
+func TestCompileNative(t *testing.T) {
+	digit := Range('0', '9')
+	verifyCompiled(t, digit.Times(2), "12", "1", "123")
+	verifyCompiled(t, Ch('a').ManyUntil(Ch('b')).And(Ch('b')), "aab", "aac")
+	verifyCompiled(t, Identifier(Range('a', 'z'), Range('a', 'z').Or(digit), "if"), "abc1", "if", "1")
+	fatal := Seq(Ch('('), digit.Fatal(), Ch(')')).Or(Ch('('))
+	verifyCompiled(t, fatal, "(1)")
+	assert.PanicsWithError(t, "parse error at row 1, col 2: unexpected x", func() {
+		_, _ = fatal.Compile().ParseToEnd("(x")
+	})
+	verifyCompiled(t, Regex(`[a-z]+`).And(Ch('\n')).And(Regex(`[0-9]+`)), "ab\n12", "ab\nx")
+}
+
+func TestCompileRecursive(t *testing.T) {
+	expr := NewParser()
+	term := OneOf(Range('0', '9'), Skip(Ch('(')).And(expr).Skip(Ch(')')))
+	expr.Set(SepByKeep(Ch('+'), term))
+	verifyCompiled(t, expr, "1+2", "(1+(2+3))+4", "((1)", "(1+)", "\n(1\n+x)")
+
+	g, err := CompileGrammar(`
+	list  <- '[' items? ']'
+	items <- item (',' item)*
+	item  <- [0-9]+ / list
+	`, nil)
+	assert.Nil(t, err)
+	verifyCompiled(t, g.Start(), "[]", "[1,[2]]", "[1,]", "[a]")
+}
+
+func TestCompileErrorPosition(t *testing.T) {
+	p := Seq(Str("let"), Chs(' ', '\n').Many1(), Range('a', 'z').Many1(), Ch(';')).Compile()
+	_, err := p.ParseToEnd("let  x1;")
+	assert.Equal(t, "parse error at row 1, col 7: expected ;", err.Error())
+	_, err = p.ParseToEnd("let\n\n x;y")
+	assert.Equal(t, "parse error at row 3, col 4: end of input not reached", err.Error())
+	_, err = p.Parse(CreateNamedInput("main.txt", "let 字"))
+	assert.Equal(t, "parse error in main.txt at row 1, col 5: unexpected 字", err.Error())
+}
+
+func TestCompileFallback(t *testing.T) {
+	p := Range('0', '9').Many1().Compile()
+	r, err := p.Parse(CreateBytesInput([]byte("12")))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{'1', '2'}, r.Result)
+	_, err = p.ParseReader(strings.NewReader("12"))
+	assert.Nil(t, err)
+	_, err = p.ParseToEndContext(context.Background(), "123", WithMaxSteps(2))
+	var stepErr *StepLimitError
+	assert.True(t, errors.As(err, &stepErr))
+}
+
+func TestCompileProgram(t *testing.T) {
+	expr := NewParser()
+	expr.Set(OneOf(Ch('x'), Skip(Ch('(')).And(expr).Skip(Ch(')'))))
+	prog := compileProgram(expr)
+	assert.Equal(t, `   0  call          2
+   1  end
+   2  choice        5
+   3  char          'x'
+   4  commit        10
+   5  char          '('
+   6  call          2
+   7  nip
+   8  char          ')'
+   9  drop
+  10  ret
+`, prog.String())
+}